@@ -16,13 +16,13 @@ func NewHandler() *Handler {
 type IndexRequest struct{}
 
 func (h *Handler) Index(ctx router.Context, req IndexRequest) (template.HTML, error) {
-	return views.Index(views.IndexData{})
+	return views.Index(ctx, views.IndexData{})
 }
 
 type GetDatasetRequest struct {
 	DatasetID int64 `json:"dataset_id"`
 }
 
-func (h *Handler) GetDataset(ctx router.Context, req GetDatasetRequest) (template.HTML, error) {
-	return views.Index(views.IndexData{})
+func (h *Handler) GetDataset(ctx router.Context, req GetDatasetRequest) (views.DatasetData, error) {
+	return views.DatasetData{DatasetID: req.DatasetID}, nil
 }
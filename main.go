@@ -4,6 +4,7 @@ import (
 	"learn-gin/handlers/home"
 	"learn-gin/pkg/router"
 	"learn-gin/pkg/urls"
+	"learn-gin/views"
 	"net/http"
 )
 
@@ -16,12 +17,12 @@ type datasetParams struct {
 var datasetPath = urls.New[datasetParams]("/ds/{dataset_id}")
 
 func main() {
-	r := router.NewRouter()
+	r := router.NewRouter().WithHTTPMiddlewares(router.Compress(router.CompressOptions{}))
 
 	homeHandler := home.NewHandler()
 
 	router.HTMLGet(r, homePath, homeHandler.Index)
-	router.HTMLGet(r, datasetPath, homeHandler.GetDataset)
+	router.Page(r, datasetPath, views.DatasetTemplate, homeHandler.GetDataset)
 
 	if err := http.ListenAndServe(":8081", r.Mux()); err != nil {
 		panic(err)
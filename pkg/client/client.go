@@ -0,0 +1,287 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"learn-gin/pkg/router"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Doer sends a http request, it is the type wrapped by the middlewares
+type Doer = func(req *http.Request) (*http.Response, error)
+
+type Middleware = func(next Doer) Doer
+
+type Client struct {
+	baseURL     string
+	httpClient  *http.Client
+	timeout     time.Duration
+	retry       RetryPolicy
+	middlewares []Middleware
+	tracer      router.Tracer
+}
+
+type Option func(c *Client)
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout sets the default timeout of a call, including all of its retries
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithRetry sets the default retry policy, retries are only applied for idempotent methods
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithMiddlewares appends middlewares, the first one is the outermost
+func WithMiddlewares(middlewares ...Middleware) Option {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// WithTracer starts the spans of the calls with the tracer when the context has no tracer,
+// by default the spans are only created inside a traced request
+func WithTracer(tracer router.Tracer) Option {
+	return func(c *Client) {
+		c.tracer = tracer
+	}
+}
+
+type callConfig struct {
+	timeout time.Duration
+	retry   RetryPolicy
+	header  http.Header
+}
+
+type CallOption func(conf *callConfig)
+
+// Timeout overrides the timeout of the client for a single call
+func Timeout(d time.Duration) CallOption {
+	return func(conf *callConfig) {
+		conf.timeout = d
+	}
+}
+
+// Retry overrides the retry policy of the client for a single call
+func Retry(policy RetryPolicy) CallOption {
+	return func(conf *callConfig) {
+		conf.retry = policy
+	}
+}
+
+// Header adds a header to the request of a single call
+func Header(key string, value string) CallOption {
+	return func(conf *callConfig) {
+		conf.header.Add(key, value)
+	}
+}
+
+// Call sends the request to the endpoint and decodes the response.
+// Error responses are returned as *router.Error.
+func Call[Req any, Resp any](
+	ctx context.Context, c *Client,
+	endpoint Endpoint[Req, Resp], req Req,
+	opts ...CallOption,
+) (Resp, error) {
+	ctx, span := c.startSpan(ctx, endpoint.method+" "+endpoint.pattern)
+	defer span.End()
+
+	span.SetAttribute("http.method", endpoint.method)
+	span.SetAttribute("http.route", endpoint.pattern)
+
+	resp, err := doCall(ctx, c, endpoint, req, opts)
+	if err != nil {
+		span.RecordError(err)
+		if status := StatusCode(err); status > 0 {
+			span.SetAttribute("http.status_code", status)
+		}
+	}
+	return resp, err
+}
+
+func (c *Client) startSpan(ctx context.Context, name string) (context.Context, router.Span) {
+	if router.TracerFromContext(ctx) == nil && c.tracer != nil {
+		return c.tracer.Start(ctx, name)
+	}
+	return router.StartSpan(ctx, name)
+}
+
+func doCall[Req any, Resp any](
+	ctx context.Context, c *Client,
+	endpoint Endpoint[Req, Resp], req Req,
+	opts []CallOption,
+) (Resp, error) {
+	var resp Resp
+
+	conf := callConfig{
+		timeout: c.timeout,
+		retry:   c.retry,
+		header:  http.Header{},
+	}
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	if conf.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, conf.timeout)
+		defer cancel()
+	}
+
+	body, err := encodeBody(endpoint, req)
+	if err != nil {
+		return resp, err
+	}
+
+	path, err := endpoint.evalURL(req)
+	if err != nil {
+		return resp, err
+	}
+	url := c.baseURL + path
+	doer := c.wrapDoer(c.httpClient.Do)
+
+	newRequest := func() (*http.Request, error) {
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, endpoint.method, url, bodyReader)
+		if err != nil {
+			return nil, err
+		}
+		if id := router.RequestIDFromContext(ctx); len(id) > 0 {
+			httpReq.Header.Set(router.RequestIDHeader, id)
+		}
+		router.InjectTraceContext(ctx, httpReq.Header)
+		if err := endpoint.setHeaders(httpReq, req); err != nil {
+			return nil, err
+		}
+		for k, values := range conf.header {
+			httpReq.Header[k] = values
+		}
+		if body != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+		httpReq.Header.Set("Accept", "application/json")
+		return httpReq, nil
+	}
+
+	retry := conf.retry
+	if !isIdempotent(endpoint.method) {
+		retry = RetryPolicy{}
+	}
+
+	httpResp, err := doWithRetry(ctx, retry, doer, newRequest)
+	if err != nil {
+		return resp, err
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return resp, decodeError(httpResp)
+	}
+
+	if httpResp.StatusCode == http.StatusNoContent || endpoint.method == http.MethodHead {
+		return resp, nil
+	}
+
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return resp, fmt.Errorf("client: decode response: %w", err)
+	}
+	return resp, nil
+}
+
+func (c *Client) wrapDoer(doer Doer) Doer {
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		doer = c.middlewares[i](doer)
+	}
+	return doer
+}
+
+// encodeBody encodes the request fields that are not path or query params
+func encodeBody[Req any, Resp any](endpoint Endpoint[Req, Resp], req Req) ([]byte, error) {
+	if !endpoint.hasBody() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("client: encode request: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("client: encode request: %w", err)
+	}
+	for key := range fields {
+		if endpoint.isParam(key) {
+			delete(fields, key)
+		}
+	}
+
+	if len(fields) == 0 && endpoint.method == http.MethodDelete {
+		return nil, nil
+	}
+	return json.Marshal(fields)
+}
+
+func decodeError(httpResp *http.Response) error {
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("client: read error response: %w", err)
+	}
+
+	var body router.ErrorBody
+	if err := json.Unmarshal(data, &body); err != nil || len(body.Error) == 0 {
+		message := strings.TrimSpace(string(data))
+		if len(message) == 0 {
+			message = http.StatusText(httpResp.StatusCode)
+		}
+		return router.NewError(httpResp.StatusCode, "", message)
+	}
+
+	return &router.Error{
+		Status:  httpResp.StatusCode,
+		Code:    body.Code,
+		Message: body.Error,
+		Details: body.Details,
+		Fields:  body.Fields,
+	}
+}
+
+// StatusCode returns the http status of an error returned by Call, or 0 if the request was not completed
+func StatusCode(err error) int {
+	var routerErr *router.Error
+	if errors.As(err, &routerErr) {
+		return routerErr.Status
+	}
+	return 0
+}
@@ -0,0 +1,315 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"learn-gin/pkg/router"
+	"learn-gin/pkg/urls"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type userParams struct {
+	UserID int64  `json:"user_id"`
+	Search string `json:"search"`
+}
+
+type userRequest struct {
+	UserID int64  `json:"user_id"`
+	Search string `json:"search"`
+	Name   string `json:"name"`
+	Age    int    `json:"age"`
+}
+
+type userResponse struct {
+	UserID int64  `json:"user_id"`
+	Name   string `json:"name"`
+	Search string `json:"search"`
+	Age    int    `json:"age"`
+}
+
+var userPath = urls.New[userParams]("/api/users/{user_id}")
+
+var getUser = Get[userParams, userRequest, userResponse](userPath)
+var updateUser = Put[userParams, userRequest, userResponse](userPath)
+var createUser = Post[userParams, userRequest, userResponse](userPath)
+
+func newTestServer(t *testing.T, register func(r *router.Router)) *httptest.Server {
+	r := router.NewRouter()
+	register(r)
+	server := httptest.NewServer(r.Mux())
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCall_Get(t *testing.T) {
+	var inputReq userRequest
+	server := newTestServer(t, func(r *router.Router) {
+		getUser.Register(r, func(ctx router.Context, req userRequest) (userResponse, error) {
+			inputReq = req
+			return userResponse{UserID: req.UserID, Search: req.Search, Name: "user01"}, nil
+		})
+	})
+
+	c := New(server.URL)
+	resp, err := Call(context.Background(), c, getUser, userRequest{
+		UserID: 123,
+		Search: "hello world",
+		Name:   "not sent",
+	})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, userResponse{UserID: 123, Search: "hello world", Name: "user01"}, resp)
+	assert.Equal(t, userRequest{UserID: 123, Search: "hello world"}, inputReq)
+}
+
+func TestCall_Put_With_Body(t *testing.T) {
+	var inputReq userRequest
+	server := newTestServer(t, func(r *router.Router) {
+		updateUser.Register(r, func(ctx router.Context, req userRequest) (userResponse, error) {
+			inputReq = req
+			return userResponse{UserID: req.UserID, Name: req.Name, Age: req.Age}, nil
+		})
+	})
+
+	c := New(server.URL)
+	resp, err := Call(context.Background(), c, updateUser, userRequest{
+		UserID: 55,
+		Name:   "new name",
+		Age:    31,
+	})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, userResponse{UserID: 55, Name: "new name", Age: 31}, resp)
+	assert.Equal(t, userRequest{UserID: 55, Name: "new name", Age: 31}, inputReq)
+}
+
+func TestCall_Error_Response(t *testing.T) {
+	server := newTestServer(t, func(r *router.Router) {
+		getUser.Register(r, func(ctx router.Context, req userRequest) (userResponse, error) {
+			return userResponse{}, router.NotFound("user not found").
+				WithDetails(map[string]any{"user_id": req.UserID})
+		})
+	})
+
+	c := New(server.URL)
+	_, err := Call(context.Background(), c, getUser, userRequest{UserID: 123})
+
+	var routerErr *router.Error
+	assert.Equal(t, true, errors.As(err, &routerErr))
+	assert.Equal(t, &router.Error{
+		Status:  http.StatusNotFound,
+		Code:    "not_found",
+		Message: "user not found",
+		Details: map[string]any{"user_id": float64(123)},
+	}, routerErr)
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+}
+
+func TestCall_Non_JSON_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		http.Error(writer, "bad gateway", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	_, err := Call(context.Background(), c, createUser, userRequest{UserID: 1})
+
+	assert.Equal(t, router.NewError(http.StatusBadGateway, "", "bad gateway"), err)
+}
+
+func TestCall_Retry(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if calls.Add(1) < 3 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = writer.Write([]byte(`{"user_id":1,"name":"user01"}`))
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	t.Run("idempotent", func(t *testing.T) {
+		calls.Store(0)
+
+		c := New(server.URL, WithRetry(policy))
+		resp, err := Call(context.Background(), c, getUser, userRequest{UserID: 1})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, userResponse{UserID: 1, Name: "user01"}, resp)
+		assert.Equal(t, int64(3), calls.Load())
+	})
+
+	t.Run("not idempotent", func(t *testing.T) {
+		calls.Store(0)
+
+		c := New(server.URL, WithRetry(policy))
+		_, err := Call(context.Background(), c, createUser, userRequest{UserID: 1})
+
+		assert.Equal(t, http.StatusServiceUnavailable, StatusCode(err))
+		assert.Equal(t, int64(1), calls.Load())
+	})
+
+	t.Run("exceed max attempts", func(t *testing.T) {
+		calls.Store(0)
+
+		c := New(server.URL)
+		_, err := Call(context.Background(), c, getUser, userRequest{UserID: 1},
+			Retry(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}),
+		)
+
+		assert.Equal(t, http.StatusServiceUnavailable, StatusCode(err))
+		assert.Equal(t, int64(2), calls.Load())
+	})
+}
+
+func TestCall_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		select {
+		case <-request.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithTimeout(time.Second))
+	_, err := Call(context.Background(), c, getUser, userRequest{UserID: 1}, Timeout(10*time.Millisecond))
+
+	assert.Equal(t, true, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestCall_Middlewares(t *testing.T) {
+	var steps []string
+	var header string
+
+	server := newTestServer(t, func(r *router.Router) {
+		getUser.Register(r, func(ctx router.Context, req userRequest) (userResponse, error) {
+			return userResponse{}, nil
+		})
+	})
+
+	c := New(server.URL, WithMiddlewares(
+		func(next Doer) Doer {
+			return func(req *http.Request) (*http.Response, error) {
+				steps = append(steps, "first")
+				req.Header.Set("Authorization", "Bearer token")
+				return next(req)
+			}
+		},
+		func(next Doer) Doer {
+			return func(req *http.Request) (*http.Response, error) {
+				steps = append(steps, "second")
+				header = req.Header.Get("Authorization") + "," + req.Header.Get("X-Custom")
+				return next(req)
+			}
+		},
+	))
+
+	_, err := Call(context.Background(), c, getUser, userRequest{UserID: 1}, Header("X-Custom", "value"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"first", "second"}, steps)
+	assert.Equal(t, "Bearer token,value", header)
+}
+
+type tenantRequest struct {
+	UserID   int64  `json:"user_id"`
+	Search   string `json:"search"`
+	Name     string `json:"name"`
+	TenantID int64  `json:"tenant_id" header:"X-Tenant-ID"`
+	Session  string `cookie:"session"`
+}
+
+func TestCall_Header_And_Cookie_Fields(t *testing.T) {
+	createTenantUser := Post[userParams, tenantRequest, userResponse](userPath)
+
+	var inputReq tenantRequest
+	var rawBody string
+	server := newTestServer(t, func(r *router.Router) {
+		r.Mux().Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				data, _ := io.ReadAll(request.Body)
+				rawBody = string(data)
+				request.Body = io.NopCloser(bytes.NewReader(data))
+				next.ServeHTTP(writer, request)
+			})
+		})
+		createTenantUser.Register(r, func(ctx router.Context, req tenantRequest) (userResponse, error) {
+			inputReq = req
+			return userResponse{}, nil
+		})
+	})
+
+	c := New(server.URL)
+	_, err := Call(context.Background(), c, createTenantUser, tenantRequest{
+		UserID:   3,
+		Name:     "user03",
+		TenantID: 77,
+		Session:  "sess",
+	})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, tenantRequest{UserID: 3, Name: "user03", TenantID: 77, Session: "sess"}, inputReq)
+	assert.Equal(t, `{"name":"user03"}`, rawBody)
+}
+
+func TestCall_Forwards_Request_ID(t *testing.T) {
+	var requestID string
+	server := newTestServer(t, func(r *router.Router) {
+		r = r.WithOptions(router.WithRequestID())
+		getUser.Register(r, func(ctx router.Context, req userRequest) (userResponse, error) {
+			requestID = ctx.RequestID()
+			return userResponse{}, nil
+		})
+	})
+
+	c := New(server.URL)
+
+	ctx := router.ContextWithRequestID(context.Background(), "req01")
+	_, err := Call(ctx, c, getUser, userRequest{UserID: 1})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "req01", requestID)
+}
+
+func TestCall_Propagates_Trace_Context(t *testing.T) {
+	exporter := router.NewInMemoryExporter()
+	tracer := router.NewTracer(exporter)
+
+	var serverSpanID string
+	server := newTestServer(t, func(r *router.Router) {
+		r = r.WithOptions(router.WithTracer(tracer))
+		getUser.Register(r, func(ctx router.Context, req userRequest) (userResponse, error) {
+			serverSpanID = ctx.Span().SpanContext().SpanIDString()
+			return userResponse{}, nil
+		})
+	})
+
+	var traceparent string
+	c := New(server.URL, WithTracer(tracer), WithMiddlewares(func(next Doer) Doer {
+		return func(req *http.Request) (*http.Response, error) {
+			traceparent = req.Header.Get("traceparent")
+			return next(req)
+		}
+	}))
+
+	_, err := Call(context.Background(), c, getUser, userRequest{UserID: 1})
+	assert.Equal(t, nil, err)
+
+	spans := exporter.Spans()
+	assert.Equal(t, 2, len(spans))
+
+	serverSpan, clientSpan := spans[0], spans[1]
+	assert.Equal(t, "/api/users/{user_id}", serverSpan.Name)
+	assert.Equal(t, serverSpanID, serverSpan.SpanID)
+	assert.Equal(t, "GET /api/users/{user_id}", clientSpan.Name)
+	assert.Equal(t, clientSpan.TraceID, serverSpan.TraceID)
+	assert.Equal(t, clientSpan.SpanID, serverSpan.ParentSpanID)
+	assert.Equal(t, "00-"+clientSpan.TraceID+"-"+clientSpan.SpanID+"-01", traceparent)
+}
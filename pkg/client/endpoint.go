@@ -0,0 +1,180 @@
+package client
+
+import (
+	"learn-gin/pkg/router"
+	"learn-gin/pkg/urls"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Endpoint describes a typed api route, it is shared between the router registration and the client calls
+type Endpoint[Req any, Resp any] struct {
+	method     string
+	pattern    string
+	pathParams []string
+	allParams  []string
+
+	headerFields []headerField
+
+	evalURL  func(req Req) (string, error)
+	register func(r *router.Router, handler func(ctx router.Context, req Req) (Resp, error), opts []router.Option)
+}
+
+func NewEndpoint[T any, Req any, Resp any](method string, path urls.Path[T]) Endpoint[Req, Resp] {
+	var testPathVal T
+	var testReqVal Req
+	urls.CheckIsSubStruct(testReqVal, testPathVal)
+
+	return Endpoint[Req, Resp]{
+		method:     method,
+		pattern:    path.GetPattern(),
+		pathParams: path.GetPathParams(),
+		allParams:  path.GetAllParams(),
+
+		headerFields: findHeaderFields(reflect.TypeFor[Req]()),
+
+		evalURL: func(req Req) (string, error) {
+			return path.TryEval(toParams[T](req))
+		},
+		register: func(
+			r *router.Router, handler func(ctx router.Context, req Req) (Resp, error), opts []router.Option,
+		) {
+			router.APIMethod(r, method, path, handler, opts...)
+		},
+	}
+}
+
+func Get[T any, Req any, Resp any](path urls.Path[T]) Endpoint[Req, Resp] {
+	return NewEndpoint[T, Req, Resp](http.MethodGet, path)
+}
+
+func Post[T any, Req any, Resp any](path urls.Path[T]) Endpoint[Req, Resp] {
+	return NewEndpoint[T, Req, Resp](http.MethodPost, path)
+}
+
+func Put[T any, Req any, Resp any](path urls.Path[T]) Endpoint[Req, Resp] {
+	return NewEndpoint[T, Req, Resp](http.MethodPut, path)
+}
+
+func Patch[T any, Req any, Resp any](path urls.Path[T]) Endpoint[Req, Resp] {
+	return NewEndpoint[T, Req, Resp](http.MethodPatch, path)
+}
+
+func Delete[T any, Req any, Resp any](path urls.Path[T]) Endpoint[Req, Resp] {
+	return NewEndpoint[T, Req, Resp](http.MethodDelete, path)
+}
+
+func (e Endpoint[Req, Resp]) Method() string {
+	return e.method
+}
+
+func (e Endpoint[Req, Resp]) Pattern() string {
+	return e.pattern
+}
+
+// Register registers the handler of the endpoint on the router
+func (e Endpoint[Req, Resp]) Register(
+	r *router.Router, handler func(ctx router.Context, req Req) (Resp, error),
+	opts ...router.Option,
+) {
+	e.register(r, handler, opts)
+}
+
+func (e Endpoint[Req, Resp]) hasBody() bool {
+	switch e.method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e Endpoint[Req, Resp]) isParam(jsonName string) bool {
+	if slices.Contains(e.allParams, jsonName) {
+		return true
+	}
+	for _, f := range e.headerFields {
+		if f.jsonKey == jsonName {
+			return true
+		}
+	}
+	return false
+}
+
+// headerField is a request field bound from a header or a cookie by the router
+type headerField struct {
+	index   int
+	name    string
+	cookie  bool
+	jsonKey string
+}
+
+func findHeaderFields(typ reflect.Type) []headerField {
+	var result []headerField
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+
+		field := headerField{index: i}
+		if name, ok := f.Tag.Lookup("header"); ok {
+			field.name = name
+		} else if name, ok := f.Tag.Lookup("cookie"); ok {
+			field.name = name
+			field.cookie = true
+		} else {
+			continue
+		}
+
+		field.jsonKey, _, _ = strings.Cut(f.Tag.Get("json"), ",")
+		if len(field.jsonKey) == 0 {
+			field.jsonKey = f.Name
+		}
+		result = append(result, field)
+	}
+	return result
+}
+
+// setHeaders sets the headers and cookies from the fields of the request
+func (e Endpoint[Req, Resp]) setHeaders(httpReq *http.Request, req Req) error {
+	reqVal := reflect.ValueOf(req)
+	for _, f := range e.headerFields {
+		field := reqVal.Field(f.index)
+		if field.IsZero() {
+			continue
+		}
+		values, ok, err := urls.FormatValues(field)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if f.cookie {
+			value, err := urls.JoinValues(f.name, values)
+			if err != nil {
+				return err
+			}
+			httpReq.AddCookie(&http.Cookie{Name: f.name, Value: value})
+			continue
+		}
+		for _, value := range values {
+			httpReq.Header.Add(f.name, value)
+		}
+	}
+	return nil
+}
+
+// toParams copies the fields of the request to the path params struct,
+// CheckIsSubStruct guarantees the fields of T exist in Req with the same types
+func toParams[T any](req any) T {
+	var params T
+	paramsVal := reflect.ValueOf(&params).Elem()
+	reqVal := reflect.ValueOf(req)
+
+	for i := 0; i < paramsVal.NumField(); i++ {
+		name := paramsVal.Type().Field(i).Name
+		paramsVal.Field(i).Set(reqVal.FieldByName(name))
+	}
+	return params
+}
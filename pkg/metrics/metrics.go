@@ -0,0 +1,411 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+// DefBuckets are the default histogram buckets, in seconds, suitable for request latencies
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry holds the metrics exposed together in the Prometheus text exposition format
+type Registry struct {
+	mut     sync.RWMutex
+	metrics map[string]*metricVec
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: map[string]*metricVec{},
+	}
+}
+
+type metricVec struct {
+	name       string
+	help       string
+	typ        metricType
+	labelNames []string
+	buckets    []float64
+
+	mut    sync.RWMutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+
+	// value is the counter or gauge value, or the sum of a histogram
+	value  atomicFloat
+	count  atomic.Uint64
+	counts []atomic.Uint64
+}
+
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) add(delta float64) {
+	for {
+		old := f.bits.Load()
+		newBits := math.Float64bits(math.Float64frombits(old) + delta)
+		if f.bits.CompareAndSwap(old, newBits) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) set(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+// register returns the metric with the name, creating it if needed.
+// It panics if a metric with the same name but another type or other labels exists.
+func (r *Registry) register(name string, help string, typ metricType, labelNames []string, buckets []float64) *metricVec {
+	if !isValidName(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name '%s'", name))
+	}
+	for _, label := range labelNames {
+		if !isValidName(label) || label == "le" {
+			panic(fmt.Sprintf("metrics: invalid label name '%s'", label))
+		}
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	existing, ok := r.metrics[name]
+	if ok {
+		if existing.typ != typ || !slices.Equal(existing.labelNames, labelNames) {
+			panic(fmt.Sprintf("metrics: metric '%s' is already registered with another type or labels", name))
+		}
+		return existing
+	}
+
+	vec := &metricVec{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: slices.Clone(labelNames),
+		buckets:    buckets,
+		series:     map[string]*series{},
+	}
+	r.metrics[name] = vec
+	return vec
+}
+
+func isValidName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == ':':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (v *metricVec) with(labelValues []string) *series {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf(
+			"metrics: metric '%s' expects %d label values, got %d",
+			v.name, len(v.labelNames), len(labelValues),
+		))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	v.mut.RLock()
+	s, ok := v.series[key]
+	v.mut.RUnlock()
+	if ok {
+		return s
+	}
+
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	s, ok = v.series[key]
+	if ok {
+		return s
+	}
+	s = &series{labelValues: slices.Clone(labelValues)}
+	if v.typ == typeHistogram {
+		s.counts = make([]atomic.Uint64, len(v.buckets))
+	}
+	v.series[key] = s
+	return s
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	s *series
+}
+
+func (c Counter) Inc() {
+	c.s.value.add(1)
+}
+
+// Add increases the counter, it panics if the delta is negative
+func (c Counter) Add(delta float64) {
+	if delta < 0 {
+		panic("metrics: counter can not decrease")
+	}
+	c.s.value.add(delta)
+}
+
+// CounterVec is a counter partitioned by label values
+type CounterVec struct {
+	vec *metricVec
+}
+
+func (r *Registry) NewCounterVec(name string, help string, labelNames ...string) CounterVec {
+	return CounterVec{vec: r.register(name, help, typeCounter, labelNames, nil)}
+}
+
+func (r *Registry) NewCounter(name string, help string) Counter {
+	return r.NewCounterVec(name, help).WithLabelValues()
+}
+
+// WithLabelValues returns the counter of the label values, given in the order of the label names
+func (c CounterVec) WithLabelValues(values ...string) Counter {
+	return Counter{s: c.vec.with(values)}
+}
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	s *series
+}
+
+func (g Gauge) Set(v float64) {
+	g.s.value.set(v)
+}
+
+func (g Gauge) Add(delta float64) {
+	g.s.value.add(delta)
+}
+
+func (g Gauge) Inc() {
+	g.s.value.add(1)
+}
+
+func (g Gauge) Dec() {
+	g.s.value.add(-1)
+}
+
+// GaugeVec is a gauge partitioned by label values
+type GaugeVec struct {
+	vec *metricVec
+}
+
+func (r *Registry) NewGaugeVec(name string, help string, labelNames ...string) GaugeVec {
+	return GaugeVec{vec: r.register(name, help, typeGauge, labelNames, nil)}
+}
+
+func (r *Registry) NewGauge(name string, help string) Gauge {
+	return r.NewGaugeVec(name, help).WithLabelValues()
+}
+
+func (g GaugeVec) WithLabelValues(values ...string) Gauge {
+	return Gauge{s: g.vec.with(values)}
+}
+
+// Histogram counts the observed values in buckets
+type Histogram struct {
+	s       *series
+	buckets []float64
+}
+
+func (h Histogram) Observe(v float64) {
+	index, _ := slices.BinarySearch(h.buckets, v)
+	if index < len(h.buckets) {
+		h.s.counts[index].Add(1)
+	}
+	h.s.count.Add(1)
+	h.s.value.add(v)
+}
+
+// HistogramVec is a histogram partitioned by label values
+type HistogramVec struct {
+	vec *metricVec
+}
+
+// NewHistogramVec registers a histogram with the upper bounds of the buckets, DefBuckets if nil
+func (r *Registry) NewHistogramVec(name string, help string, buckets []float64, labelNames ...string) HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	buckets = slices.Compact(buckets)
+	if len(buckets) > 0 && math.IsInf(buckets[len(buckets)-1], 1) {
+		buckets = buckets[:len(buckets)-1]
+	}
+	return HistogramVec{vec: r.register(name, help, typeHistogram, labelNames, buckets)}
+}
+
+func (r *Registry) NewHistogram(name string, help string, buckets []float64) Histogram {
+	return r.NewHistogramVec(name, help, buckets).WithLabelValues()
+}
+
+func (h HistogramVec) WithLabelValues(values ...string) Histogram {
+	return Histogram{s: h.vec.with(values), buckets: h.vec.buckets}
+}
+
+// ExponentialBuckets returns count buckets starting at start, each one factor times the previous
+func ExponentialBuckets(start float64, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
+
+// WriteText writes all the metrics in the Prometheus text exposition format, sorted by name
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mut.RLock()
+	vecs := make([]*metricVec, 0, len(r.metrics))
+	for _, vec := range r.metrics {
+		vecs = append(vecs, vec)
+	}
+	r.mut.RUnlock()
+
+	slices.SortFunc(vecs, func(a, b *metricVec) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	buf := bufio.NewWriter(w)
+	for _, vec := range vecs {
+		vec.writeText(buf)
+	}
+	return buf.Flush()
+}
+
+func (v *metricVec) writeText(w *bufio.Writer) {
+	v.mut.RLock()
+	seriesList := make([]*series, 0, len(v.series))
+	for _, s := range v.series {
+		seriesList = append(seriesList, s)
+	}
+	v.mut.RUnlock()
+
+	if len(seriesList) == 0 {
+		return
+	}
+
+	slices.SortFunc(seriesList, func(a, b *series) int {
+		return slices.Compare(a.labelValues, b.labelValues)
+	})
+
+	if len(v.help) > 0 {
+		fmt.Fprintf(w, "# HELP %s %s\n", v.name, escapeHelp(v.help))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.name, v.typ)
+
+	for _, s := range seriesList {
+		labels := formatLabels(v.labelNames, s.labelValues, "", "")
+
+		if v.typ != typeHistogram {
+			fmt.Fprintf(w, "%s%s %s\n", v.name, labels, formatFloat(s.value.load()))
+			continue
+		}
+
+		var cumulative uint64
+		for i, bound := range v.buckets {
+			cumulative += s.counts[i].Load()
+			bucketLabels := formatLabels(v.labelNames, s.labelValues, "le", formatFloat(bound))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, bucketLabels, cumulative)
+		}
+		count := s.count.Load()
+		fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, formatLabels(v.labelNames, s.labelValues, "le", "+Inf"), count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", v.name, labels, formatFloat(s.value.load()))
+		fmt.Fprintf(w, "%s_count%s %d\n", v.name, labels, count)
+	}
+}
+
+func formatLabels(names []string, values []string, extraName string, extraValue string) string {
+	if len(names) == 0 && len(extraName) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(values[i]))
+		b.WriteByte('"')
+	}
+	if len(extraName) > 0 {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(extraName)
+		b.WriteString(`="`)
+		b.WriteString(extraValue)
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var (
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// Handler returns a handler serving the metrics, it can be mounted on a router or served by a separate listener
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.WriteText(writer)
+	})
+}
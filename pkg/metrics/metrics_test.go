@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func writeText(t *testing.T, r *Registry) string {
+	var buf bytes.Buffer
+	assert.Equal(t, nil, r.WriteText(&buf))
+	return buf.String()
+}
+
+func TestRegistry_WriteText(t *testing.T) {
+	t.Run("counter and gauge", func(t *testing.T) {
+		r := NewRegistry()
+
+		jobs := r.NewCounterVec("jobs_total", "Total jobs.\nWith new line.", "queue", "state")
+		jobs.WithLabelValues("default", "done").Add(2)
+		jobs.WithLabelValues("default", "done").Inc()
+		jobs.WithLabelValues(`say "hi"`, "failed").Inc()
+
+		workers := r.NewGauge("workers", "")
+		workers.Set(5)
+		workers.Dec()
+
+		assert.Equal(t, `# HELP jobs_total Total jobs.\nWith new line.
+# TYPE jobs_total counter
+jobs_total{queue="default",state="done"} 3
+jobs_total{queue="say \"hi\"",state="failed"} 1
+# TYPE workers gauge
+workers 4
+`, writeText(t, r))
+	})
+
+	t.Run("histogram", func(t *testing.T) {
+		r := NewRegistry()
+
+		h := r.NewHistogramVec("latency_seconds", "Latency.", []float64{1, 0.1, 0.5}, "route")
+		h.WithLabelValues("/a").Observe(0.05)
+		h.WithLabelValues("/a").Observe(0.1)
+		h.WithLabelValues("/a").Observe(0.7)
+		h.WithLabelValues("/a").Observe(3)
+
+		assert.Equal(t, `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 2
+latency_seconds_bucket{route="/a",le="0.5"} 2
+latency_seconds_bucket{route="/a",le="1"} 3
+latency_seconds_bucket{route="/a",le="+Inf"} 4
+latency_seconds_sum{route="/a"} 3.85
+latency_seconds_count{route="/a"} 4
+`, writeText(t, r))
+	})
+
+	t.Run("empty metrics are skipped", func(t *testing.T) {
+		r := NewRegistry()
+		r.NewCounterVec("requests_total", "Requests.", "route")
+		assert.Equal(t, "", writeText(t, r))
+	})
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+
+	a := r.NewCounterVec("requests_total", "Requests.", "route")
+	b := r.NewCounterVec("requests_total", "Requests.", "route")
+	a.WithLabelValues("/a").Inc()
+	b.WithLabelValues("/a").Inc()
+	assert.Contains(t, writeText(t, r), `requests_total{route="/a"} 2`)
+
+	assert.PanicsWithValue(t, "metrics: metric 'requests_total' is already registered with another type or labels", func() {
+		r.NewGaugeVec("requests_total", "Requests.", "route")
+	})
+	assert.PanicsWithValue(t, "metrics: invalid metric name '1requests'", func() {
+		r.NewCounter("1requests", "")
+	})
+	assert.PanicsWithValue(t, "metrics: invalid label name 'le'", func() {
+		r.NewHistogramVec("latency", "", nil, "le")
+	})
+	assert.PanicsWithValue(t, "metrics: metric 'requests_total' expects 1 label values, got 2", func() {
+		a.WithLabelValues("/a", "GET")
+	})
+	assert.PanicsWithValue(t, "metrics: counter can not decrease", func() {
+		a.WithLabelValues("/a").Add(-1)
+	})
+}
+
+func TestCounter_Concurrent(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("hits_total", "", "route")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.WithLabelValues("/a").Inc()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, "# TYPE hits_total counter\nhits_total{route=\"/a\"} 8000\n", writeText(t, r))
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("up", "").Inc()
+
+	writer := httptest.NewRecorder()
+	r.Handler().ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", writer.Header().Get("Content-Type"))
+	assert.Equal(t, "# TYPE up counter\nup 1\n", writer.Body.String())
+}
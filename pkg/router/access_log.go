@@ -0,0 +1,200 @@
+package router
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"learn-gin/pkg/null"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccessLogOptions configures the AccessLog middleware
+type AccessLogOptions struct {
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+
+	// Levels maps a status class (2 for 2xx, 4 for 4xx, ...) to the level of the records,
+	// by default 5xx are logged as errors, 4xx as warnings and the others as info
+	Levels map[int]slog.Level
+
+	// SampleRate is the fraction of the responses with status < 400 that are logged,
+	// zero means all of them, the error responses are always logged
+	SampleRate float64
+
+	// LogRequest adds the bound request fields to the records,
+	// the fields tagged with `log:"redact"` are replaced by a placeholder
+	LogRequest bool
+
+	// TrustForwardedFor takes the client ip from the X-Forwarded-For header,
+	// it must only be enabled behind a proxy setting this header
+	TrustForwardedFor bool
+}
+
+const redactedValue = "[REDACTED]"
+
+// AccessLog returns a middleware logging a record for each request, with the route pattern
+// instead of the raw path to keep the cardinality of the values low
+func AccessLog(opts AccessLogOptions) HTTPMiddleware {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(info RouteInfo, next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			start := time.Now()
+			recorder := newResponseRecorder(writer)
+
+			next.ServeHTTP(recorder, request)
+
+			status := recorder.Status()
+			if status < http.StatusBadRequest && opts.SampleRate > 0 && rand.Float64() >= opts.SampleRate {
+				return
+			}
+
+			level := accessLogLevel(opts.Levels, status)
+			ctx := request.Context()
+			if !logger.Enabled(ctx, level) {
+				return
+			}
+
+			route := info.Pattern
+			if routeCtx := chi.RouteContext(ctx); routeCtx != nil && len(routeCtx.RoutePatterns) > 0 {
+				route = routeCtx.RoutePattern()
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", request.Method),
+				slog.String("route", route),
+				slog.Int("status", status),
+				slog.Duration("latency", time.Since(start)),
+				slog.Int64("bytes", recorder.bytes),
+				slog.String("client_ip", clientIP(request, opts.TrustForwardedFor)),
+			}
+			if requestID := requestIDOf(request); len(requestID) > 0 {
+				attrs = append(attrs, slog.String("request_id", requestID))
+			}
+
+			state, _ := requestStateOf(request)
+			if state != nil && len(state.errCode) > 0 {
+				attrs = append(attrs, slog.String("error_code", state.errCode))
+			}
+			if opts.LogRequest && state != nil && state.req != nil {
+				attrs = append(attrs, slog.Attr{
+					Key:   "request",
+					Value: slog.GroupValue(redactedAttrs(reflect.ValueOf(state.req))...),
+				})
+			}
+
+			logger.LogAttrs(context.WithoutCancel(ctx), level, "request completed", attrs...)
+		})
+	}
+}
+
+func accessLogLevel(levels map[int]slog.Level, status int) slog.Level {
+	if level, ok := levels[status/100]; ok {
+		return level
+	}
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func clientIP(request *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := request.Header.Get("X-Forwarded-For"); len(forwarded) > 0 {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return host
+}
+
+type logField struct {
+	index  int
+	name   string
+	redact bool
+	nested bool
+}
+
+var logFieldsCache sync.Map
+
+// logFields returns the fields of a struct logged with their json names
+func logFields(typ reflect.Type) []logField {
+	cached, ok := logFieldsCache.Load(typ)
+	if ok {
+		return cached.([]logField)
+	}
+
+	var fields []logField
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		name := fieldType.Name
+		if jsonName := computeJsonName(fieldType.Tag.Get("json")); jsonName == "-" {
+			continue
+		} else if len(jsonName) > 0 {
+			name = jsonName
+		}
+
+		fields = append(fields, logField{
+			index:  i,
+			name:   name,
+			redact: fieldType.Tag.Get("log") == "redact",
+			nested: isNestedLogStruct(fieldType.Type),
+		})
+	}
+
+	logFieldsCache.Store(typ, fields)
+	return fields
+}
+
+func isNestedLogStruct(typ reflect.Type) bool {
+	if !isStructType(typ) || typ == timeType || typ == fileType {
+		return false
+	}
+	_, _, isNull := null.IsNullType(reflect.New(typ).Elem())
+	return !isNull
+}
+
+func redactedAttrs(val reflect.Value) []slog.Attr {
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := logFields(val.Type())
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		fieldVal := val.Field(f.index)
+		switch {
+		case f.redact:
+			attrs = append(attrs, slog.String(f.name, redactedValue))
+		case f.nested:
+			attrs = append(attrs, slog.Attr{Key: f.name, Value: slog.GroupValue(redactedAttrs(fieldVal)...)})
+		case fieldVal.Type() == fileType:
+			attrs = append(attrs, slog.String(f.name, fieldVal.Interface().(File).Name))
+		default:
+			attrs = append(attrs, slog.Any(f.name, fieldVal.Interface()))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,147 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"learn-gin/pkg/urls"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type loginParams struct {
+	UserID userID `json:"user_id"`
+}
+
+var loginPath = urls.New[loginParams]("/api/users/{user_id}")
+
+type loginRequest struct {
+	UserID   userID `json:"user_id"`
+	Username string `json:"username"`
+	Password string `json:"password" log:"redact"`
+	Profile  struct {
+		Phone string `json:"phone" log:"redact"`
+		City  string `json:"city"`
+	} `json:"profile"`
+}
+
+func newAccessLogRouter(buf *bytes.Buffer, opts AccessLogOptions) *Router {
+	opts.Logger = slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	r := NewRouter().WithOptions(WithRequestID()).WithHTTPMiddlewares(AccessLog(opts))
+
+	APIPost(r, loginPath, func(ctx Context, req loginRequest) (userGetResponse, error) {
+		if req.Username == "missing" {
+			return userGetResponse{}, NotFound("user not found")
+		}
+		return userGetResponse{UserID: req.UserID, Username: req.Username}, nil
+	})
+	return r
+}
+
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var result []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		var record map[string]any
+		assert.Equal(t, nil, json.Unmarshal([]byte(line), &record))
+		delete(record, "time")
+		delete(record, "latency")
+		result = append(result, record)
+	}
+	return result
+}
+
+func TestAccessLog(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		var buf bytes.Buffer
+		r := newAccessLogRouter(&buf, AccessLogOptions{LogRequest: true})
+
+		body := `{"username":"user01","password":"secret","profile":{"phone":"0123","city":"Hanoi"}}`
+		req := httptest.NewRequest(http.MethodPost, "/api/users/123", strings.NewReader(body))
+		req.Header.Set("X-Request-ID", "req01")
+		req.RemoteAddr = "10.0.0.1:4567"
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, []map[string]any{
+			{
+				"level":      "INFO",
+				"msg":        "request completed",
+				"method":     "POST",
+				"route":      "/api/users/{user_id}",
+				"status":     float64(200),
+				"bytes":      float64(writer.Body.Len()),
+				"client_ip":  "10.0.0.1",
+				"request_id": "req01",
+				"request": map[string]any{
+					"user_id":  float64(123),
+					"username": "user01",
+					"password": "[REDACTED]",
+					"profile": map[string]any{
+						"phone": "[REDACTED]",
+						"city":  "Hanoi",
+					},
+				},
+			},
+		}, decodeLogLines(t, &buf))
+		assert.False(t, strings.Contains(buf.String(), "secret"))
+	})
+
+	t.Run("error with custom level", func(t *testing.T) {
+		var buf bytes.Buffer
+		r := newAccessLogRouter(&buf, AccessLogOptions{
+			Levels:            map[int]slog.Level{4: slog.LevelInfo},
+			TrustForwardedFor: true,
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/users/123", strings.NewReader(`{"username":"missing"}`))
+		req.Header.Set("X-Forwarded-For", "1.2.3.4, 10.0.0.1")
+		r.Mux().ServeHTTP(httptest.NewRecorder(), req)
+
+		records := decodeLogLines(t, &buf)
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, "INFO", records[0]["level"])
+		assert.Equal(t, float64(404), records[0]["status"])
+		assert.Equal(t, "not_found", records[0]["error_code"])
+		assert.Equal(t, "1.2.3.4", records[0]["client_ip"])
+		assert.Equal(t, nil, records[0]["request"])
+	})
+
+	t.Run("sampling keeps errors", func(t *testing.T) {
+		var buf bytes.Buffer
+		r := newAccessLogRouter(&buf, AccessLogOptions{SampleRate: 0.0000001})
+
+		for i := 0; i < 10; i++ {
+			req := httptest.NewRequest(http.MethodPost, "/api/users/123", strings.NewReader(`{"username":"user01"}`))
+			r.Mux().ServeHTTP(httptest.NewRecorder(), req)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/users/123", strings.NewReader(`{"username":"missing"}`))
+		r.Mux().ServeHTTP(httptest.NewRecorder(), req)
+
+		records := decodeLogLines(t, &buf)
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, "WARN", records[0]["level"])
+	})
+
+	t.Run("mounted router uses full pattern", func(t *testing.T) {
+		var buf bytes.Buffer
+		sub := newAccessLogRouter(&buf, AccessLogOptions{})
+
+		root := NewRouter()
+		Mount(root, datasetPrefix, sub)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/datasets/5/api/users/123", strings.NewReader(`{}`))
+		r := httptest.NewRecorder()
+		root.Mux().ServeHTTP(r, req)
+
+		records := decodeLogLines(t, &buf)
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, "/api/datasets/{dataset_id}/api/users/{user_id}", records[0]["route"])
+	})
+}
@@ -0,0 +1,363 @@
+package router
+
+import (
+	"encoding"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"learn-gin/pkg/null"
+	"learn-gin/pkg/urls"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type paramSource int
+
+const (
+	sourcePath paramSource = iota + 1
+	sourceQuery
+	sourceHeader
+	sourceCookie
+	sourceForm
+	sourceFile
+)
+
+type bindingField struct {
+	index   int
+	name    string
+	source  paramSource
+	convert fieldConverter
+}
+
+// bindingPlan is the precomputed list of fields bound from the path, the query string,
+// the headers and the cookies of a request
+type bindingPlan struct {
+	fields   []bindingField
+	hasQuery bool
+}
+
+func newBindingPlan(typ reflect.Type, path routePath) *bindingPlan {
+	plan := &bindingPlan{}
+	if typ.Kind() != reflect.Struct {
+		return plan
+	}
+
+	var headerFields []bindingField
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+
+		field := bindingField{index: i}
+
+		if name, ok := fieldType.Tag.Lookup("header"); ok {
+			field.name = textproto.CanonicalMIMEHeaderKey(name)
+			field.source = sourceHeader
+			field.convert = newFieldConverter(fieldType.Type, fieldType.Name, false)
+			headerFields = append(headerFields, field)
+			continue
+		}
+		if name, ok := fieldType.Tag.Lookup("cookie"); ok {
+			field.name = name
+			field.source = sourceCookie
+			field.convert = newFieldConverter(fieldType.Type, fieldType.Name, true)
+			headerFields = append(headerFields, field)
+			continue
+		}
+
+		field.name = computeJsonName(fieldType.Tag.Get("json"))
+		switch {
+		case slices.Contains(path.pathParams, field.name):
+			field.source = sourcePath
+			field.convert = newFieldConverter(fieldType.Type, fieldType.Name, true)
+		case slices.Contains(path.allParams, field.name):
+			field.source = sourceQuery
+			field.convert = newFieldConverter(fieldType.Type, fieldType.Name, urls.IsCommaSeparated(fieldType))
+			plan.hasQuery = true
+		default:
+			continue
+		}
+		plan.fields = append(plan.fields, field)
+	}
+
+	// header fields are bound last, so they can not be set by the other sources
+	plan.fields = append(plan.fields, headerFields...)
+	return plan
+}
+
+// newFormPlan returns the plan of the fields bound from the form fields and the files of a form body,
+// the header and cookie fields are only bound by the plan of newBindingPlan
+func newFormPlan(typ reflect.Type) *bindingPlan {
+	plan := &bindingPlan{}
+	if typ.Kind() != reflect.Struct {
+		return plan
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+		name := computeJsonName(fieldType.Tag.Get("json"))
+		if len(name) == 0 || name == "-" || isHeaderField(fieldType) {
+			continue
+		}
+
+		field := bindingField{index: i, name: name, source: sourceForm}
+		if isFileType(fieldType.Type) {
+			field.source = sourceFile
+		} else {
+			field.convert = newFieldConverter(fieldType.Type, fieldType.Name, urls.IsCommaSeparated(fieldType))
+		}
+		plan.fields = append(plan.fields, field)
+	}
+	return plan
+}
+
+// bindForm assigns the values and the files of a parsed form to the fields of req, which must be a pointer to a struct
+func (p *bindingPlan) bindForm(values url.Values, files map[string][]*multipart.FileHeader, req any) error {
+	val := reflect.ValueOf(req).Elem()
+
+	for _, field := range p.fields {
+		if field.source == sourceFile {
+			setFileField(val.Field(field.index), files[field.name])
+			continue
+		}
+
+		fieldValues := values[field.name]
+		if isEmptyValues(fieldValues) {
+			continue
+		}
+		if err := field.convert(val.Field(field.index), fieldValues); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bind assigns the params of the request to the fields of req, which must be a pointer to a struct.
+// The header and cookie fields are reset before binding, so they can never be set by the request body.
+func (p *bindingPlan) bind(request *http.Request, req any) error {
+	val := reflect.ValueOf(req).Elem()
+
+	var query url.Values
+	if p.hasQuery {
+		query = request.URL.Query()
+	}
+
+	for _, field := range p.fields {
+		var values []string
+
+		switch field.source {
+		case sourcePath:
+			value := chi.URLParam(request, field.name)
+			// chi matches the escaped path only if the request has one, otherwise the path is already decoded
+			if len(request.URL.RawPath) > 0 {
+				if unescaped, err := url.PathUnescape(value); err == nil {
+					value = unescaped
+				}
+			}
+			if len(value) > 0 {
+				values = []string{value}
+			}
+		case sourceQuery:
+			values = query[field.name]
+		case sourceHeader:
+			values = request.Header[field.name]
+			val.Field(field.index).SetZero()
+		case sourceCookie:
+			cookie, err := request.Cookie(field.name)
+			if err == nil && len(cookie.Value) > 0 {
+				values = []string{cookie.Value}
+			}
+			val.Field(field.index).SetZero()
+		}
+
+		if isEmptyValues(values) {
+			continue
+		}
+
+		if err := field.convert(val.Field(field.index), values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isEmptyValues(values []string) bool {
+	return len(values) == 0 || (len(values) == 1 && len(values[0]) == 0)
+}
+
+// fieldConverter sets a field from the non-empty list of values of a param
+type fieldConverter func(f reflect.Value, values []string) error
+
+// valueConverter sets a scalar field from a single value
+type valueConverter func(f reflect.Value, value string) error
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	durationType        = reflect.TypeFor[time.Duration]()
+)
+
+// newFieldConverter sets a slice field from repeated values, or from a single comma separated value if comma is set.
+// Other fields are set from the first value.
+func newFieldConverter(typ reflect.Type, fieldName string, comma bool) fieldConverter {
+	if !isSliceParam(typ) {
+		convert := newValueConverter(typ, fieldName)
+		return func(f reflect.Value, values []string) error {
+			return convert(f, values[0])
+		}
+	}
+
+	convert := newValueConverter(typ.Elem(), fieldName)
+	return func(f reflect.Value, values []string) error {
+		if comma && len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+
+		slice := reflect.MakeSlice(typ, len(values), len(values))
+		for i, value := range values {
+			if err := convert(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		f.Set(slice)
+		return nil
+	}
+}
+
+func isSliceParam(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Slice {
+		return false
+	}
+	return !reflect.PointerTo(typ).Implements(textUnmarshalerType)
+}
+
+func newValueConverter(typ reflect.Type, fieldName string) valueConverter {
+	parseErr := func(value string) error {
+		return &ParseError{Value: value, Field: fieldName}
+	}
+
+	switch typ {
+	case timeType:
+		return func(f reflect.Value, value string) error {
+			t, err := parseTime(value)
+			if err != nil {
+				return parseErr(value)
+			}
+			f.Set(reflect.ValueOf(t))
+			return nil
+		}
+
+	case durationType:
+		return func(f reflect.Value, value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return parseErr(value)
+			}
+			f.SetInt(int64(d))
+			return nil
+		}
+	}
+
+	if reflect.PointerTo(typ).Implements(textUnmarshalerType) {
+		return func(f reflect.Value, value string) error {
+			unmarshaler := f.Addr().Interface().(encoding.TextUnmarshaler)
+			if err := unmarshaler.UnmarshalText([]byte(value)); err != nil {
+				return parseErr(value)
+			}
+			return nil
+		}
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return func(f reflect.Value, value string) error {
+			f.SetString(value)
+			return nil
+		}
+
+	case reflect.Bool:
+		return func(f reflect.Value, value string) error {
+			boolVal, err := strconv.ParseBool(value)
+			if err != nil {
+				return parseErr(value)
+			}
+			f.SetBool(boolVal)
+			return nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := typ.Bits()
+		return func(f reflect.Value, value string) error {
+			intVal, err := strconv.ParseInt(value, 10, bits)
+			if err != nil {
+				return parseErr(value)
+			}
+			f.SetInt(intVal)
+			return nil
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		bits := typ.Bits()
+		return func(f reflect.Value, value string) error {
+			intVal, err := strconv.ParseUint(value, 10, bits)
+			if err != nil {
+				return parseErr(value)
+			}
+			f.SetUint(intVal)
+			return nil
+		}
+
+	case reflect.Float32, reflect.Float64:
+		bits := typ.Bits()
+		return func(f reflect.Value, value string) error {
+			floatVal, err := strconv.ParseFloat(value, bits)
+			if err != nil {
+				return parseErr(value)
+			}
+			f.SetFloat(floatVal)
+			return nil
+		}
+
+	case reflect.Pointer:
+		elemType := typ.Elem()
+		convert := newValueConverter(elemType, fieldName)
+		return func(f reflect.Value, value string) error {
+			elem := reflect.New(elemType)
+			if err := convert(elem.Elem(), value); err != nil {
+				return err
+			}
+			f.Set(elem)
+			return nil
+		}
+	}
+
+	if _, dataVal, ok := null.IsNullType(reflect.New(typ).Elem()); ok {
+		convert := newValueConverter(dataVal.Type(), fieldName)
+		return func(f reflect.Value, value string) error {
+			validVal, dataVal, _ := null.IsNullType(f)
+			validVal.SetBool(true)
+			return convert(dataVal, value)
+		}
+	}
+
+	return func(f reflect.Value, value string) error {
+		return fmt.Errorf(
+			"unrecognized field type '%s' of field '%s'",
+			typ.Kind(), fieldName,
+		)
+	}
+}
+
+// parseTime accepts RFC 3339 timestamps and plain dates
+func parseTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
@@ -0,0 +1,184 @@
+package router
+
+import (
+	"github.com/stretchr/testify/assert"
+	"learn-gin/pkg/null"
+	"learn-gin/pkg/urls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type filterParams struct {
+	TenantID int64             `json:"tenant_id"`
+	Search   string            `json:"search"`
+	Status   []string          `json:"status"`
+	MinAge   int               `json:"min_age"`
+	MaxAge   int               `json:"max_age"`
+	Active   bool              `json:"active"`
+	Score    float64           `json:"score"`
+	Country  null.Null[string] `json:"country"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
+
+type filterRequest struct {
+	TenantID int64             `json:"tenant_id"`
+	Search   string            `json:"search"`
+	Status   []string          `json:"status"`
+	MinAge   int               `json:"min_age"`
+	MaxAge   int               `json:"max_age"`
+	Active   bool              `json:"active"`
+	Score    float64           `json:"score"`
+	Country  null.Null[string] `json:"country"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+	Trace    string            `header:"X-Trace"`
+}
+
+var filterPath = urls.New[filterParams]("/api/tenants/{tenant_id}/users")
+
+func newFilterRequest() *http.Request {
+	query := url.Values{
+		"search":    {"john"},
+		"status":    {"active", "pending"},
+		"min_age":   {"18"},
+		"max_age":   {"65"},
+		"active":    {"true"},
+		"score":     {"0.5"},
+		"country":   {"VN"},
+		"page":      {"2"},
+		"page_size": {"50"},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/tenants/7/users?"+query.Encode(), nil)
+	req.Header.Set("X-Trace", "trace01")
+	return req
+}
+
+func TestBindingPlan(t *testing.T) {
+	r := NewRouter()
+
+	var inputReq filterRequest
+	APIGet(r, filterPath, func(ctx Context, req filterRequest) (urls.Empty, error) {
+		inputReq = req
+		return urls.Empty{}, nil
+	})
+
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, newFilterRequest())
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, filterRequest{
+		TenantID: 7,
+		Search:   "john",
+		Status:   []string{"active", "pending"},
+		MinAge:   18,
+		MaxAge:   65,
+		Active:   true,
+		Score:    0.5,
+		Country:  null.New("VN"),
+		Page:     2,
+		PageSize: 50,
+		Trace:    "trace01",
+	}, inputReq)
+}
+
+func TestNewBindingPlan_Sources(t *testing.T) {
+	path := newRoutePath(NewRouter(), filterPath)
+	plan := newBindingPlan(reflect.TypeFor[filterRequest](), path)
+
+	sources := map[string]paramSource{}
+	for _, f := range plan.fields {
+		sources[f.name] = f.source
+	}
+
+	assert.Equal(t, true, plan.hasQuery)
+	assert.Equal(t, sourcePath, sources["tenant_id"])
+	assert.Equal(t, sourceQuery, sources["page_size"])
+	assert.Equal(t, sourceHeader, sources["X-Trace"])
+	assert.Equal(t, sourceHeader, plan.fields[len(plan.fields)-1].source)
+}
+
+func BenchmarkBindingPlan_Bind(b *testing.B) {
+	path := newRoutePath(NewRouter(), filterPath)
+	plan := newBindingPlan(reflect.TypeFor[filterRequest](), path)
+
+	r := NewRouter()
+	var request *http.Request
+	APIGet(r, filterPath, func(ctx Context, req filterRequest) (urls.Empty, error) {
+		request = ctx.request
+		return urls.Empty{}, nil
+	})
+	r.Mux().ServeHTTP(httptest.NewRecorder(), newFilterRequest())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var req filterRequest
+		if err := plan.bind(request, &req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAPIGet_Many_Filters(b *testing.B) {
+	r := NewRouter()
+	APIGet(r, filterPath, func(ctx Context, req filterRequest) (urls.Empty, error) {
+		return urls.Empty{}, nil
+	})
+	request := newFilterRequest()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Mux().ServeHTTP(httptest.NewRecorder(), request)
+	}
+}
+
+type headerReqBody struct {
+	Name     string            `json:"name"`
+	TenantID int64             `json:"tenant_id" header:"X-Tenant-ID"`
+	Session  string            `cookie:"session"`
+	Trace    null.Null[string] `header:"X-Trace"`
+}
+
+func TestBindingPlan_Header_Fields(t *testing.T) {
+	plan := newBindingPlan(reflect.TypeFor[headerReqBody](), routePath{})
+
+	t.Run("normal", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Tenant-ID", "42")
+		req.Header.Set("X-Trace", "abc")
+		req.AddCookie(&http.Cookie{Name: "session", Value: "sess01"})
+
+		body := headerReqBody{Name: "user01", TenantID: 99, Session: "from body"}
+		err := plan.bind(req, &body)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, headerReqBody{
+			Name:     "user01",
+			TenantID: 42,
+			Session:  "sess01",
+			Trace:    null.New("abc"),
+		}, body)
+	})
+
+	t.Run("missing headers reset body values", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		body := headerReqBody{Name: "user01", TenantID: 99, Session: "from body"}
+		err := plan.bind(req, &body)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, headerReqBody{Name: "user01"}, body)
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Tenant-ID", "ABC")
+
+		var body headerReqBody
+		err := plan.bind(req, &body)
+		assert.Equal(t, &ParseError{Field: "TenantID", Value: "ABC"}, err)
+	})
+}
@@ -0,0 +1,204 @@
+package router
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const defaultMultipartMemory = 32 << 20
+
+// File is a file uploaded with a multipart/form-data request
+type File struct {
+	Name        string
+	Size        int64
+	ContentType string
+
+	header *multipart.FileHeader
+}
+
+func newFile(header *multipart.FileHeader) File {
+	return File{
+		Name:        header.Filename,
+		Size:        header.Size,
+		ContentType: header.Header.Get("Content-Type"),
+		header:      header,
+	}
+}
+
+// Open opens the uploaded content, it is only valid during the handling of the request
+func (f File) Open() (multipart.File, error) {
+	if f.header == nil {
+		return nil, errors.New("router: file is not uploaded")
+	}
+	return f.header.Open()
+}
+
+var fileType = reflect.TypeFor[File]()
+
+// decodeBody decodes the json body into req, or the form body with the form plan if the route decodes forms
+func (r *Router) decodeBody(request *http.Request, rule bodyRule, form *bindingPlan, req any) error {
+	if rule == bodyNone {
+		return nil
+	}
+
+	if err := r.checkContentType(request, form != nil); err != nil {
+		return err
+	}
+	if r.config.maxBodySize > 0 && request.Body != nil {
+		request.Body = http.MaxBytesReader(nil, request.Body, r.config.maxBodySize)
+	}
+	if err := r.decompressBody(request); err != nil {
+		return bodyTooLargeError(err)
+	}
+
+	if form != nil {
+		mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+		switch mediaType {
+		case "application/x-www-form-urlencoded":
+			return r.decodeForm(request, form, req, false)
+		case "multipart/form-data":
+			return r.decodeForm(request, form, req, true)
+		}
+	}
+
+	return r.decodeJSONBody(request, rule, req)
+}
+
+// removeMultipartFiles deletes the temporary files of the multipart form of the request,
+// net/http only removes the files of the form parsed on its own request, not on the copies made by the middlewares
+func removeMultipartFiles(request *http.Request) {
+	if request.MultipartForm != nil {
+		_ = request.MultipartForm.RemoveAll()
+	}
+}
+
+func (r *Router) decodeForm(request *http.Request, form *bindingPlan, req any, multipartForm bool) error {
+	if r.config.multipartMaxSize > 0 {
+		request.Body = http.MaxBytesReader(nil, request.Body, r.config.multipartMaxSize)
+	}
+
+	var err error
+	if multipartForm {
+		maxMemory := r.config.multipartMaxMemory
+		if maxMemory <= 0 {
+			maxMemory = defaultMultipartMemory
+		}
+		err = request.ParseMultipartForm(maxMemory)
+	} else {
+		err = request.ParseForm()
+	}
+	if err != nil {
+		return bodyTooLargeError(err)
+	}
+
+	var files map[string][]*multipart.FileHeader
+	if request.MultipartForm != nil {
+		files = request.MultipartForm.File
+	}
+	return form.bindForm(request.PostForm, files, req)
+}
+
+func isFileType(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Pointer, reflect.Slice:
+		return typ.Elem() == fileType
+	default:
+		return typ == fileType
+	}
+}
+
+func setFileField(f reflect.Value, headers []*multipart.FileHeader) {
+	if len(headers) == 0 {
+		return
+	}
+
+	switch f.Kind() {
+	case reflect.Slice:
+		files := make([]File, 0, len(headers))
+		for _, h := range headers {
+			files = append(files, newFile(h))
+		}
+		f.Set(reflect.ValueOf(files))
+
+	case reflect.Pointer:
+		file := newFile(headers[0])
+		f.Set(reflect.ValueOf(&file))
+
+	default:
+		f.Set(reflect.ValueOf(newFile(headers[0])))
+	}
+}
+
+// bodyTooLargeError converts the errors of http.MaxBytesReader to a 413 error
+func bodyTooLargeError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return NewError(
+			http.StatusRequestEntityTooLarge, "request_too_large",
+			fmt.Sprintf("request body exceeds the limit of %d bytes", maxBytesErr.Limit),
+		).WithCause(err)
+	}
+	return err
+}
+
+const defaultDecompressedMaxSize = 10 << 20
+
+// decompressBody replaces a gzip or deflate encoded body by its decompressed content
+func (r *Router) decompressBody(request *http.Request) error {
+	encoding := strings.ToLower(strings.TrimSpace(request.Header.Get("Content-Encoding")))
+	if len(encoding) == 0 || encoding == "identity" || request.Body == nil || request.Body == http.NoBody {
+		return nil
+	}
+
+	var reader io.ReadCloser
+	var err error
+	switch encoding {
+	case encodingGzip, "x-gzip":
+		reader, err = gzip.NewReader(request.Body)
+	case encodingDeflate:
+		reader, err = zlib.NewReader(request.Body)
+	default:
+		return NewError(
+			http.StatusUnsupportedMediaType, "unsupported_encoding",
+			fmt.Sprintf("unsupported content encoding '%s'", encoding),
+		)
+	}
+	if errors.Is(err, io.EOF) {
+		request.Body = http.NoBody
+		return nil
+	}
+	if err != nil {
+		return NewError(http.StatusBadRequest, "invalid_encoding", "invalid compressed request body").WithCause(err)
+	}
+
+	maxSize := r.config.decompressedMaxSize
+	if maxSize <= 0 {
+		maxSize = defaultDecompressedMaxSize
+	}
+
+	request.Body = decompressedBody{
+		Reader: http.MaxBytesReader(nil, reader, maxSize),
+		body:   request.Body,
+	}
+	request.Header.Del("Content-Encoding")
+	request.Header.Del("Content-Length")
+	request.ContentLength = -1
+	return nil
+}
+
+type decompressedBody struct {
+	io.Reader
+	body io.Closer
+}
+
+func (b decompressedBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,273 @@
+package router
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"html/template"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+type userFormRequest struct {
+	UserID userID `json:"user_id"`
+	Search string `json:"search"`
+	Age    int    `json:"age"`
+	Body   string `json:"body"`
+	Count  uint32 `json:"count"`
+}
+
+func TestHTMLPost_URL_Encoded_Form(t *testing.T) {
+	r := NewRouter()
+
+	var inputReq userFormRequest
+	HTMLPost(r, userPath, func(ctx Context, req userFormRequest) (template.HTML, error) {
+		inputReq = req
+		return "<div>Saved</div>", nil
+	})
+
+	form := url.Values{
+		"body":    []string{"Some Body"},
+		"count":   []string{"12"},
+		"user_id": []string{"999"},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123?age=20", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, userFormRequest{
+		UserID: 123,
+		Age:    20,
+		Body:   "Some Body",
+		Count:  12,
+	}, inputReq)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "<div>Saved</div>", writer.Body.String())
+}
+
+func TestHTMLPost_Form_Parse_Error(t *testing.T) {
+	r := NewRouter()
+
+	HTMLPost(r, userPath, func(ctx Context, req userFormRequest) (template.HTML, error) {
+		return "<div>Saved</div>", nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", strings.NewReader("count=AB"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusBadRequest, writer.Code)
+	assert.Equal(t,
+		`{"error":"router: can not parse value 'AB' into field 'Count'"}`+"\n",
+		writer.Body.String(),
+	)
+}
+
+type uploadRequest struct {
+	UserID      userID `json:"user_id"`
+	Search      string `json:"search"`
+	Age         int    `json:"age"`
+	Title       string `json:"title"`
+	Avatar      File   `json:"avatar" validate:"required"`
+	Attachments []File `json:"attachments"`
+	Cover       *File  `json:"cover"`
+}
+
+func newMultipartBody(t *testing.T, fields map[string]string, files map[string][]string) (*bytes.Buffer, string) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		assert.Equal(t, nil, w.WriteField(k, v))
+	}
+	for k, contents := range files {
+		for i, content := range contents {
+			part, err := w.CreateFormFile(k, k+string(rune('0'+i))+".txt")
+			assert.Equal(t, nil, err)
+			_, _ = part.Write([]byte(content))
+		}
+	}
+	assert.Equal(t, nil, w.Close())
+	return &buf, w.FormDataContentType()
+}
+
+func TestHTMLPost_Multipart_Form(t *testing.T) {
+	r := NewRouter()
+
+	var inputReq uploadRequest
+	var avatarContent string
+	HTMLPost(r, userPath, func(ctx Context, req uploadRequest) (template.HTML, error) {
+		inputReq = req
+
+		file, err := req.Avatar.Open()
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = file.Close() }()
+
+		data, err := io.ReadAll(file)
+		avatarContent = string(data)
+		return "<div>Uploaded</div>", err
+	})
+
+	body, contentType := newMultipartBody(t,
+		map[string]string{"title": "My Title"},
+		map[string][]string{
+			"avatar":      {"avatar content"},
+			"attachments": {"first", "second file"},
+		},
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", body)
+	req.Header.Set("Content-Type", contentType)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "<div>Uploaded</div>", writer.Body.String())
+
+	assert.Equal(t, userID(123), inputReq.UserID)
+	assert.Equal(t, "My Title", inputReq.Title)
+	assert.Equal(t, "avatar content", avatarContent)
+
+	assert.Equal(t, "avatar0.txt", inputReq.Avatar.Name)
+	assert.Equal(t, int64(14), inputReq.Avatar.Size)
+	assert.Equal(t, "application/octet-stream", inputReq.Avatar.ContentType)
+
+	assert.Equal(t, 2, len(inputReq.Attachments))
+	assert.Equal(t, "attachments1.txt", inputReq.Attachments[1].Name)
+	assert.Equal(t, int64(11), inputReq.Attachments[1].Size)
+
+	assert.Nil(t, inputReq.Cover)
+}
+
+func TestHTMLPost_Multipart_Form_Missing_File(t *testing.T) {
+	r := NewRouter()
+
+	HTMLPost(r, userPath, func(ctx Context, req uploadRequest) (template.HTML, error) {
+		return "<div>Uploaded</div>", nil
+	})
+
+	body, contentType := newMultipartBody(t, map[string]string{"title": "My Title"}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, writer.Code)
+	assert.Equal(t,
+		`{"error":"request validation failed","code":"validation_failed","fields":[`+
+			`{"field":"Avatar","path":"avatar","rule":"required","message":"is required"}]}`+"\n",
+		writer.Body.String(),
+	)
+}
+
+func TestHTMLPost_Multipart_Form_Too_Large(t *testing.T) {
+	r := NewRouter().WithOptions(WithMultipartLimits(16, 64))
+
+	called := false
+	HTMLPost(r, userPath, func(ctx Context, req uploadRequest) (template.HTML, error) {
+		called = true
+		return "<div>Uploaded</div>", nil
+	})
+
+	body, contentType := newMultipartBody(t, nil, map[string][]string{
+		"avatar": {strings.Repeat("x", 200)},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, false, called)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, writer.Code)
+	assert.Equal(t,
+		`{"error":"request body exceeds the limit of 64 bytes","code":"request_too_large"}`+"\n",
+		writer.Body.String(),
+	)
+}
+
+func TestAPIPost_Form_Body(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		r := NewRouter()
+
+		APIPost(r, userPath, func(ctx Context, req userFormRequest) (userGetResponse, error) {
+			return userGetResponse{}, nil
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/users/123", strings.NewReader("body=hello"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+
+		assert.Equal(t, http.StatusBadRequest, writer.Code)
+	})
+
+	t.Run("enabled per route", func(t *testing.T) {
+		r := NewRouter()
+
+		var inputReq userFormRequest
+		APIPost(r, userPath, func(ctx Context, req userFormRequest) (userGetResponse, error) {
+			inputReq = req
+			return userGetResponse{Username: req.Body}, nil
+		}, WithFormBody())
+
+		req := httptest.NewRequest(http.MethodPost, "/api/users/123", strings.NewReader("body=hello"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, userFormRequest{UserID: 123, Body: "hello"}, inputReq)
+		assert.Equal(t, `{"user_id":0,"username":"hello"}`+"\n", writer.Body.String())
+	})
+}
+
+func TestHTMLPost_Multipart_Form_Removes_Temporary_Files(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+
+	r := NewRouter().WithOptions(WithMultipartLimits(1024, 0))
+
+	var avatarSize int
+	HTMLPost(r, userPath, func(ctx Context, req uploadRequest) (template.HTML, error) {
+		file, err := req.Avatar.Open()
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = file.Close() }()
+
+		data, err := io.ReadAll(file)
+		avatarSize = len(data)
+		return "<div>Uploaded</div>", err
+	})
+
+	body, contentType := newMultipartBody(t, nil, map[string][]string{
+		"avatar": {strings.Repeat("x", 1<<20)},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", body)
+	req.Header.Set("Content-Type", contentType)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, 1<<20, avatarSize)
+
+	entries, err := os.ReadDir(tempDir)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(entries))
+}
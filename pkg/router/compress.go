@@ -0,0 +1,381 @@
+package router
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+
+	defaultCompressMinSize = 1024
+)
+
+// DefaultCompressibleTypes are the media types compressed when CompressOptions.ContentTypes is empty
+var DefaultCompressibleTypes = []string{
+	"text/html",
+	"text/css",
+	"text/plain",
+	"text/csv",
+	"text/javascript",
+	"text/xml",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/problem+json",
+	"image/svg+xml",
+}
+
+// CompressOptions configures the Compress middleware
+type CompressOptions struct {
+	// Level is the gzip/flate compression level, gzip.DefaultCompression if zero
+	Level int
+	// MinSize is the minimum size in bytes of a compressed response body, 1024 if zero
+	MinSize int
+	// ContentTypes are the compressed media types, DefaultCompressibleTypes if empty.
+	// A type ending with "/*" matches all the subtypes.
+	ContentTypes []string
+}
+
+// Compress returns a middleware compressing the response bodies with gzip or deflate,
+// as negotiated with the Accept-Encoding header of the request.
+// A body is only compressed if its content type is compressible and its size reaches the threshold,
+// a flushed response is compressed without waiting for the threshold.
+func Compress(opts CompressOptions) HTTPMiddleware {
+	if opts.Level == 0 {
+		opts.Level = gzip.DefaultCompression
+	}
+	if opts.Level < gzip.HuffmanOnly || opts.Level > gzip.BestCompression {
+		panic(fmt.Sprintf("router: invalid compression level %d", opts.Level))
+	}
+	if opts.MinSize <= 0 {
+		opts.MinSize = defaultCompressMinSize
+	}
+	if len(opts.ContentTypes) == 0 {
+		opts.ContentTypes = DefaultCompressibleTypes
+	}
+
+	pools := compressorPools{
+		gzip: &sync.Pool{New: func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, opts.Level)
+			return w
+		}},
+		deflate: &sync.Pool{New: func() any {
+			w, _ := zlib.NewWriterLevel(io.Discard, opts.Level)
+			return w
+		}},
+	}
+
+	return func(info RouteInfo, next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.Method == http.MethodHead {
+				hw := &headVaryWriter{ResponseWriter: writer, opts: &opts}
+				defer hw.writeVary()
+				next.ServeHTTP(hw, request)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: writer,
+				opts:           &opts,
+				pools:          pools,
+				encoding:       negotiateEncoding(request.Header.Get("Accept-Encoding")),
+			}
+			defer cw.close()
+
+			next.ServeHTTP(cw, request)
+		})
+	}
+}
+
+type compressorPools struct {
+	gzip    *sync.Pool
+	deflate *sync.Pool
+}
+
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+func (p compressorPools) get(encoding string, w io.Writer) compressor {
+	var c compressor
+	switch encoding {
+	case encodingGzip:
+		c = p.gzip.Get().(*gzip.Writer)
+	default:
+		c = p.deflate.Get().(*zlib.Writer)
+	}
+	c.Reset(w)
+	return c
+}
+
+func (p compressorPools) put(encoding string, c compressor) {
+	c.Reset(io.Discard)
+	switch encoding {
+	case encodingGzip:
+		p.gzip.Put(c)
+	default:
+		p.deflate.Put(c)
+	}
+}
+
+// negotiateEncoding returns gzip or deflate, preferring gzip on equal quality, or an empty string
+func negotiateEncoding(header string) string {
+	if len(header) == 0 {
+		return ""
+	}
+
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(key) == "q" {
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil {
+					q = 0
+				}
+				quality = q
+			}
+		}
+		qualities[name] = quality
+	}
+
+	qualityOf := func(encoding string) float64 {
+		if q, ok := qualities[encoding]; ok {
+			return q
+		}
+		return qualities["*"]
+	}
+
+	best, bestQuality := "", 0.0
+	for _, encoding := range []string{encodingGzip, encodingDeflate} {
+		if q := qualityOf(encoding); q > bestQuality {
+			best, bestQuality = encoding, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the beginning of the body until the decision to compress can be made
+type compressWriter struct {
+	http.ResponseWriter
+	opts     *CompressOptions
+	pools    compressorPools
+	encoding string
+
+	status     int
+	buf        []byte
+	decided    bool
+	compressor compressor
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status != 0 {
+		return
+	}
+	if status < 200 && status != http.StatusSwitchingProtocols {
+		// informational responses, e.g. 103 Early Hints, precede the final response
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	w.status = status
+	if !bodyAllowed(status) {
+		_ = w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if !w.decided {
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.opts.MinSize {
+			return len(data), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+
+	if w.compressor != nil {
+		return w.compressor.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// decide writes the headers and the buffered body, compressed if the response is eligible
+func (w *compressWriter) decide(sizeReached bool) error {
+	w.decided = true
+
+	header := w.Header()
+	if w.isCompressible() {
+		header.Add("Vary", "Accept-Encoding")
+		if sizeReached && len(w.encoding) > 0 {
+			header.Set("Content-Encoding", w.encoding)
+			header.Del("Content-Length")
+			if etag := header.Get("ETag"); len(etag) > 0 && !strings.HasPrefix(etag, "W/") {
+				header.Set("ETag", "W/"+etag)
+			}
+			w.compressor = w.pools.get(w.encoding, w.ResponseWriter)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.compressor != nil {
+		_, err := w.compressor.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *compressWriter) isCompressible() bool {
+	header := w.Header()
+	if len(header.Get("Content-Encoding")) > 0 || !bodyAllowed(w.status) || isRangeResponse(w.status, header) {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if len(contentType) == 0 {
+		if len(w.buf) == 0 {
+			return false
+		}
+		contentType = http.DetectContentType(w.buf)
+		header.Set("Content-Type", contentType)
+	}
+	return w.opts.compressibleType(contentType)
+}
+
+// isRangeResponse returns true when the response serves byte ranges, the ranges would not match a compressed body
+func isRangeResponse(status int, header http.Header) bool {
+	return status == http.StatusPartialContent ||
+		len(header.Get("Content-Range")) > 0 ||
+		strings.EqualFold(header.Get("Accept-Ranges"), "bytes")
+}
+
+func (opts *CompressOptions) compressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	return slices.Contains(opts.ContentTypes, mediaType) || slices.Contains(opts.ContentTypes, mainType+"/*")
+}
+
+// headVaryWriter adds to the responses of the HEAD requests, which are never compressed,
+// the Vary header of the corresponding GET responses, so that the caches see the same headers
+type headVaryWriter struct {
+	http.ResponseWriter
+	opts    *CompressOptions
+	written bool
+}
+
+func (w *headVaryWriter) writeVary() {
+	w.writeVaryStatus(http.StatusOK)
+}
+
+func (w *headVaryWriter) writeVaryStatus(status int) {
+	if w.written || (status < 200 && status != http.StatusSwitchingProtocols) {
+		return
+	}
+	w.written = true
+
+	header := w.Header()
+	if len(header.Get("Content-Encoding")) > 0 || !bodyAllowed(status) || isRangeResponse(status, header) {
+		return
+	}
+	if w.opts.compressibleType(header.Get("Content-Type")) {
+		header.Add("Vary", "Accept-Encoding")
+	}
+}
+
+func (w *headVaryWriter) WriteHeader(status int) {
+	w.writeVaryStatus(status)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *headVaryWriter) Write(data []byte) (int, error) {
+	w.writeVary()
+	return w.ResponseWriter.Write(data)
+}
+
+// Unwrap is used by http.ResponseController to access the underlying writer
+func (w *headVaryWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush compresses the response without waiting for the size threshold
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		_ = w.decide(true)
+	}
+	if w.compressor != nil {
+		_ = w.compressor.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack is used by the websocket handlers, the response is not compressed
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	w.decided = true
+	return hijacker.Hijack()
+}
+
+// Unwrap is used by http.ResponseController to access the underlying writer
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *compressWriter) close() {
+	if !w.decided {
+		if w.status == 0 {
+			return
+		}
+		_ = w.decide(false)
+	}
+	if w.compressor != nil {
+		_ = w.compressor.Close()
+		w.pools.put(w.encoding, w.compressor)
+		w.compressor = nil
+	}
+}
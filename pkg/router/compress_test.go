@@ -0,0 +1,233 @@
+package router
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	for header, expected := range map[string]string{
+		"":                        "",
+		"gzip":                    "gzip",
+		"deflate":                 "deflate",
+		"gzip, deflate, br":       "gzip",
+		"deflate, gzip;q=0.5":     "deflate",
+		"gzip;q=0, deflate":       "deflate",
+		"*":                       "gzip",
+		"*;q=0.2, gzip;q=0":       "deflate",
+		"br, identity":            "",
+		"GZIP;q=0.8, deflate;q=1": "deflate",
+	} {
+		assert.Equal(t, expected, negotiateEncoding(header), header)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	r := NewRouter().WithHTTPMiddlewares(Compress(CompressOptions{MinSize: 100}))
+
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		switch req.Search {
+		case "small":
+			return userGetResponse{UserID: req.UserID, Username: "user01"}, nil
+		case "png":
+			ctx.Respond(Bytes("image/png", bytes.Repeat([]byte{1}, 500)))
+			return userGetResponse{}, nil
+		case "etag":
+			ctx.SetETag("v1")
+		case "range":
+			ctx.SetHeader("Content-Range", "bytes 0-4999/12000")
+			ctx.SetStatusCode(http.StatusPartialContent)
+		case "ranges":
+			ctx.SetHeader("Accept-Ranges", "bytes")
+		}
+		return userGetResponse{UserID: req.UserID, Username: strings.Repeat("a", 500)}, nil
+	})
+
+	expectedBody := `{"user_id":123,"username":"` + strings.Repeat("a", 500) + `"}` + "\n"
+
+	serve := func(method string, url string, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		if len(acceptEncoding) > 0 {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+		return writer
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		writer := serve(http.MethodGet, "/api/users/123", "gzip, deflate")
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, "gzip", writer.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", writer.Header().Get("Vary"))
+		assert.Equal(t, "application/json; charset=utf-8", writer.Header().Get("Content-Type"))
+
+		reader, err := gzip.NewReader(writer.Body)
+		assert.Equal(t, nil, err)
+		data, err := io.ReadAll(reader)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, expectedBody, string(data))
+	})
+
+	t.Run("deflate", func(t *testing.T) {
+		writer := serve(http.MethodGet, "/api/users/123", "deflate")
+		assert.Equal(t, "deflate", writer.Header().Get("Content-Encoding"))
+
+		reader, err := zlib.NewReader(writer.Body)
+		assert.Equal(t, nil, err)
+		data, err := io.ReadAll(reader)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, expectedBody, string(data))
+	})
+
+	t.Run("not accepted", func(t *testing.T) {
+		writer := serve(http.MethodGet, "/api/users/123", "")
+		assert.Equal(t, "", writer.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", writer.Header().Get("Vary"))
+		assert.Equal(t, expectedBody, writer.Body.String())
+	})
+
+	t.Run("below threshold", func(t *testing.T) {
+		writer := serve(http.MethodGet, "/api/users/123?search=small", "gzip")
+		assert.Equal(t, "", writer.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", writer.Header().Get("Vary"))
+		assert.Equal(t, `{"user_id":123,"username":"user01"}`+"\n", writer.Body.String())
+	})
+
+	t.Run("not compressible", func(t *testing.T) {
+		writer := serve(http.MethodGet, "/api/users/123?search=png", "gzip")
+		assert.Equal(t, "", writer.Header().Get("Content-Encoding"))
+		assert.Equal(t, "", writer.Header().Get("Vary"))
+		assert.Equal(t, 500, writer.Body.Len())
+	})
+
+	t.Run("weak etag", func(t *testing.T) {
+		writer := serve(http.MethodGet, "/api/users/123?search=etag", "gzip")
+		assert.Equal(t, "gzip", writer.Header().Get("Content-Encoding"))
+		assert.Equal(t, `W/"v1"`, writer.Header().Get("ETag"))
+	})
+
+	t.Run("partial content", func(t *testing.T) {
+		writer := serve(http.MethodGet, "/api/users/123?search=range", "gzip")
+		assert.Equal(t, http.StatusPartialContent, writer.Code)
+		assert.Equal(t, "", writer.Header().Get("Content-Encoding"))
+		assert.Equal(t, "bytes 0-4999/12000", writer.Header().Get("Content-Range"))
+		assert.Equal(t, expectedBody, writer.Body.String())
+	})
+
+	t.Run("byte ranges", func(t *testing.T) {
+		writer := serve(http.MethodGet, "/api/users/123?search=ranges", "gzip")
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, "", writer.Header().Get("Content-Encoding"))
+		assert.Equal(t, expectedBody, writer.Body.String())
+	})
+
+	t.Run("head", func(t *testing.T) {
+		writer := serve(http.MethodHead, "/api/users/123", "gzip")
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, "", writer.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", writer.Header().Get("Vary"))
+		assert.Equal(t, 0, writer.Body.Len())
+	})
+
+	t.Run("head not compressible", func(t *testing.T) {
+		writer := serve(http.MethodHead, "/api/users/123?search=png", "gzip")
+		assert.Equal(t, "", writer.Header().Get("Vary"))
+	})
+}
+
+func TestCompress_Flush(t *testing.T) {
+	r := NewRouter().WithHTTPMiddlewares(Compress(CompressOptions{}))
+
+	SSE(r, jobEventsPath, func(ctx Context, req jobEventsRequest, events *EventSender[jobProgress]) error {
+		return events.Send(jobProgress{Percent: 10})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/jobs/1/events", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, true, writer.Flushed)
+	assert.Equal(t, "", writer.Header().Get("Content-Encoding"))
+	assert.Equal(t, "data: {\"percent\":10}\n\n", writer.Body.String())
+}
+
+func TestAPIPost_Compressed_Body(t *testing.T) {
+	r := NewRouter().WithOptions(WithDecompressedBodyLimit(1000))
+
+	var inputReq userPostRequest
+	APIPost(r, userPath, func(ctx Context, req userPostRequest) (userGetResponse, error) {
+		inputReq = req
+		return userGetResponse{}, nil
+	})
+
+	gzipData := func(text string) []byte {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, _ = w.Write([]byte(text))
+		_ = w.Close()
+		return buf.Bytes()
+	}
+
+	serve := func(encoding string, body []byte) *httptest.ResponseRecorder {
+		inputReq = userPostRequest{}
+		req := httptest.NewRequest(http.MethodPost, "/api/users/123", bytes.NewReader(body))
+		req.Header.Set("Content-Encoding", encoding)
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+		return writer
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		writer := serve("gzip", gzipData(`{"body":"Some Body"}`))
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, userPostRequest{UserID: 123, Body: "Some Body"}, inputReq)
+	})
+
+	t.Run("deflate", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		_, _ = w.Write([]byte(`{"body":"Deflated"}`))
+		_ = w.Close()
+
+		writer := serve("deflate", buf.Bytes())
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, userPostRequest{UserID: 123, Body: "Deflated"}, inputReq)
+	})
+
+	t.Run("decompressed size limit", func(t *testing.T) {
+		body := `{"body":"` + strings.Repeat("a", 2000) + `"}`
+		writer := serve("gzip", gzipData(body))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, writer.Code)
+		assert.Equal(t,
+			`{"error":"request body exceeds the limit of 1000 bytes","code":"request_too_large"}`+"\n",
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("invalid gzip", func(t *testing.T) {
+		writer := serve("gzip", []byte(`{"body":"plain"}`))
+		assert.Equal(t, http.StatusBadRequest, writer.Code)
+		assert.Equal(t,
+			`{"error":"invalid compressed request body","code":"invalid_encoding"}`+"\n",
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("unsupported encoding", func(t *testing.T) {
+		writer := serve("br", []byte(`{}`))
+		assert.Equal(t, http.StatusUnsupportedMediaType, writer.Code)
+		assert.Equal(t,
+			`{"error":"unsupported content encoding 'br'","code":"unsupported_encoding"}`+"\n",
+			writer.Body.String(),
+		)
+	})
+}
@@ -3,10 +3,32 @@ package router
 import (
 	"context"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type requestState struct {
-	code int
+	code      int
+	responder Responder
+	stack     []byte
+	events    any
+
+	// req is the bound request, err and errCode describe the error response if any
+	req     any
+	err     error
+	errCode string
+}
+
+type requestStateKey struct{}
+
+// withRequestState shares the state of the request between the http middlewares and the Context
+func withRequestState(request *http.Request, state *requestState) *http.Request {
+	return request.WithContext(context.WithValue(request.Context(), requestStateKey{}, state))
+}
+
+func requestStateOf(request *http.Request) (*requestState, bool) {
+	state, ok := request.Context().Value(requestStateKey{}).(*requestState)
+	return state, ok
 }
 
 type Context struct {
@@ -16,10 +38,14 @@ type Context struct {
 }
 
 func NewContext(writer http.ResponseWriter, req *http.Request) Context {
+	state, ok := requestStateOf(req)
+	if !ok {
+		state = &requestState{}
+	}
 	return Context{
 		request: req,
 		writer:  writer,
-		state:   &requestState{},
+		state:   state,
 	}
 }
 
@@ -27,12 +53,85 @@ func (c Context) Context() context.Context {
 	return c.request.Context()
 }
 
+// Deadline, Done, Err and Value make the Context a context.Context of the request,
+// e.g. to render the views with the csrf token and the span of the request
+func (c Context) Deadline() (time.Time, bool) {
+	return c.Context().Deadline()
+}
+
+func (c Context) Done() <-chan struct{} {
+	return c.Context().Done()
+}
+
+func (c Context) Err() error {
+	return c.Context().Err()
+}
+
+func (c Context) Value(key any) any {
+	return c.Context().Value(key)
+}
+
 func (c Context) WithContext(ctx context.Context) Context {
 	newCtx := c
 	newCtx.request = c.request.WithContext(ctx)
 	return newCtx
 }
 
+// SetStatusCode sets the status of the response, it is used for both successful and failed responses,
+// except the errors of type *Error which carry their own status
 func (c Context) SetStatusCode(code int) {
 	c.state.code = code
 }
+
+// StatusCode returns the status set by SetStatusCode, zero if not set
+func (c Context) StatusCode() int {
+	return c.state.code
+}
+
+// Header returns the headers of the response, they are written before the response body
+func (c Context) Header() http.Header {
+	return c.writer.Header()
+}
+
+// SetHeader sets a header of the response
+func (c Context) SetHeader(key string, value string) {
+	c.writer.Header().Set(key, value)
+}
+
+// SetCookie adds a Set-Cookie header to the response
+func (c Context) SetCookie(cookie *http.Cookie) {
+	http.SetCookie(c.writer, cookie)
+}
+
+// SetCacheControl sets the Cache-Control header from the directives, e.g. "public", "max-age=3600"
+func (c Context) SetCacheControl(directives ...string) {
+	c.writer.Header().Set("Cache-Control", strings.Join(directives, ", "))
+}
+
+// SetETag sets the ETag header, the tag is quoted if it is not already
+func (c Context) SetETag(tag string) {
+	if !strings.HasPrefix(tag, `"`) && !strings.HasPrefix(tag, `W/"`) {
+		tag = `"` + tag + `"`
+	}
+	c.writer.Header().Set("ETag", tag)
+}
+
+// successStatus returns the status of a successful response
+func (c Context) successStatus() int {
+	if c.state.code == 0 {
+		return http.StatusOK
+	}
+	return c.state.code
+}
+
+// bodyAllowed returns false for the statuses that must not have a response body
+func bodyAllowed(status int) bool {
+	switch {
+	case status >= 100 && status < 200:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,204 @@
+package router
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions is the cross-origin resource sharing policy of the routes
+type CORSOptions struct {
+	// AllowedOrigins are exact origins (https://app.example.com), wildcard subdomains (https://*.example.com)
+	// or "*" for all origins
+	AllowedOrigins []string
+	// AllowOriginFunc allows the origins not matched by AllowedOrigins
+	AllowOriginFunc func(origin string) bool
+	// AllowedMethods restricts the methods allowed by the preflight requests, the method of the route if empty
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed by the preflight requests, "*" allows all headers
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers readable by the scripts
+	ExposedHeaders []string
+	// AllowCredentials allows the cookies and the authorization headers, it can not be used with the "*" origin
+	AllowCredentials bool
+	// MaxAge is the duration the preflight responses can be cached
+	MaxAge time.Duration
+}
+
+type corsPolicy struct {
+	opts      CORSOptions
+	allOrigin bool
+	exact     []string
+	wildcards []originWildcard
+	allHeader bool
+	headers   []string
+	methods   []string
+}
+
+type originWildcard struct {
+	prefix string
+	suffix string
+}
+
+// WithCORS answers the preflight requests and sets the CORS headers of the responses of the routes
+func WithCORS(opts CORSOptions) Option {
+	policy := newCORSPolicy(opts)
+	return func(conf *routerConfig) {
+		conf.cors = policy
+	}
+}
+
+func newCORSPolicy(opts CORSOptions) *corsPolicy {
+	p := &corsPolicy{opts: opts}
+
+	for _, origin := range opts.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		switch {
+		case origin == "*":
+			p.allOrigin = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			if !strings.HasSuffix(prefix, "://") || !strings.HasPrefix(suffix, ".") || strings.Contains(suffix, "*") {
+				panic("router: invalid cors origin '" + origin + "', the wildcard must be the first label of the host")
+			}
+			p.wildcards = append(p.wildcards, originWildcard{prefix: prefix, suffix: suffix})
+		default:
+			p.exact = append(p.exact, origin)
+		}
+	}
+	if p.allOrigin && opts.AllowCredentials {
+		panic("router: cors credentials can not be allowed for all origins")
+	}
+
+	for _, header := range opts.AllowedHeaders {
+		if header == "*" {
+			p.allHeader = true
+			continue
+		}
+		p.headers = append(p.headers, http.CanonicalHeaderKey(header))
+	}
+	for _, method := range opts.AllowedMethods {
+		p.methods = append(p.methods, strings.ToUpper(method))
+	}
+	return p
+}
+
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	if p.allOrigin {
+		return true
+	}
+
+	lower := strings.ToLower(origin)
+	if slices.Contains(p.exact, lower) {
+		return true
+	}
+	for _, w := range p.wildcards {
+		if !strings.HasPrefix(lower, w.prefix) || !strings.HasSuffix(lower, w.suffix) {
+			continue
+		}
+		sub := lower[len(w.prefix) : len(lower)-len(w.suffix)]
+		if len(sub) > 0 && !strings.ContainsAny(sub, "/:@") {
+			return true
+		}
+	}
+	return p.opts.AllowOriginFunc != nil && p.opts.AllowOriginFunc(origin)
+}
+
+func (p *corsPolicy) allowMethod(method string) bool {
+	return len(p.methods) == 0 || slices.Contains(p.methods, method)
+}
+
+func (p *corsPolicy) allowHeaders(requested []string) bool {
+	if p.allHeader {
+		return true
+	}
+	for _, header := range requested {
+		if !slices.Contains(p.headers, http.CanonicalHeaderKey(header)) {
+			return false
+		}
+	}
+	return true
+}
+
+// varyOrigin returns false when the response headers are the same for all origins
+func (p *corsPolicy) varyOrigin() bool {
+	return !p.allOrigin
+}
+
+// setOriginHeaders sets the headers shared by the preflight and the actual responses
+func (p *corsPolicy) setOriginHeaders(header http.Header, origin string) {
+	if p.allOrigin {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.opts.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// corsHandler sets the CORS headers of the actual responses
+func corsHandler(policy *corsPolicy, handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		header := writer.Header()
+		if policy.varyOrigin() {
+			header.Add("Vary", "Origin")
+		}
+
+		origin := request.Header.Get("Origin")
+		if len(origin) > 0 && policy.allowOrigin(origin) {
+			policy.setOriginHeaders(header, origin)
+			if len(policy.opts.ExposedHeaders) > 0 {
+				header.Set("Access-Control-Expose-Headers", strings.Join(policy.opts.ExposedHeaders, ", "))
+			}
+		}
+		handler(writer, request)
+	}
+}
+
+func isPreflight(request *http.Request) bool {
+	return request.Method == http.MethodOptions &&
+		len(request.Header.Get("Origin")) > 0 &&
+		len(request.Header.Get("Access-Control-Request-Method")) > 0
+}
+
+// writePreflight answers a preflight request with the policy of the route of the requested method,
+// the CORS headers are omitted when the request is not allowed so that the browser rejects it
+func writePreflight(writer http.ResponseWriter, request *http.Request, policy *corsPolicy) {
+	header := writer.Header()
+	header.Add("Vary", "Origin")
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+
+	origin := request.Header.Get("Origin")
+	method := strings.ToUpper(request.Header.Get("Access-Control-Request-Method"))
+	requestedHeaders := parseHeaderList(request.Header.Get("Access-Control-Request-Headers"))
+
+	if !policy.allowOrigin(origin) || !policy.allowMethod(method) || !policy.allowHeaders(requestedHeaders) {
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	policy.setOriginHeaders(header, origin)
+	header.Set("Access-Control-Allow-Methods", method)
+	if len(requestedHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(requestedHeaders, ", "))
+	}
+	if policy.opts.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(policy.opts.MaxAge.Seconds())))
+	}
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func parseHeaderList(value string) []string {
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) > 0 {
+			result = append(result, strings.ToLower(part))
+		}
+	}
+	return result
+}
@@ -0,0 +1,191 @@
+package router
+
+import (
+	"github.com/stretchr/testify/assert"
+	"learn-gin/pkg/urls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORSPolicy_Allow_Origin(t *testing.T) {
+	policy := newCORSPolicy(CORSOptions{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+		AllowOriginFunc: func(origin string) bool {
+			return strings.HasSuffix(origin, ".localhost:3000")
+		},
+	})
+
+	for origin, expected := range map[string]bool{
+		"https://app.example.com":       true,
+		"HTTPS://APP.EXAMPLE.COM":       true,
+		"https://other.example.com":     false,
+		"http://app.example.com":        false,
+		"https://a.example.org":         true,
+		"https://a.b.example.org":       true,
+		"https://example.org":           false,
+		"https://.example.org":          false,
+		"https://evil.com/.example.org": false,
+		"https://a.example.org.evil":    false,
+		"http://dev.localhost:3000":     true,
+		"null":                          false,
+	} {
+		assert.Equal(t, expected, policy.allowOrigin(origin), origin)
+	}
+}
+
+func TestNewCORSPolicy_Invalid(t *testing.T) {
+	assert.PanicsWithValue(t, "router: cors credentials can not be allowed for all origins", func() {
+		WithCORS(CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	})
+	assert.PanicsWithValue(t,
+		"router: invalid cors origin 'https://api.*.example.com', the wildcard must be the first label of the host",
+		func() {
+			WithCORS(CORSOptions{AllowedOrigins: []string{"https://api.*.example.com"}})
+		},
+	)
+}
+
+func TestWithCORS(t *testing.T) {
+	r := NewRouter()
+
+	api := r.WithOptions(WithCORS(CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedHeaders:   []string{"Content-Type", "X-Request-ID"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}))
+
+	APIGet(api, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		return userGetResponse{UserID: req.UserID}, nil
+	})
+	APIPost(api, userPath, func(ctx Context, req userPostRequest) (userGetResponse, error) {
+		return userGetResponse{UserID: req.UserID}, nil
+	})
+	APIDelete(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+
+	serve := func(method string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/users/123", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+		return writer
+	}
+
+	t.Run("preflight", func(t *testing.T) {
+		writer := serve(http.MethodOptions, http.Header{
+			"Origin":                         {"https://app.example.com"},
+			"Access-Control-Request-Method":  {"POST"},
+			"Access-Control-Request-Headers": {"content-type, x-request-id"},
+		})
+		assert.Equal(t, http.StatusNoContent, writer.Code)
+		assert.Equal(t, http.Header{
+			"Allow":                            {"DELETE, GET, HEAD, OPTIONS, POST"},
+			"Vary":                             {"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+			"Access-Control-Allow-Origin":      {"https://app.example.com"},
+			"Access-Control-Allow-Credentials": {"true"},
+			"Access-Control-Allow-Methods":     {"POST"},
+			"Access-Control-Allow-Headers":     {"content-type, x-request-id"},
+			"Access-Control-Max-Age":           {"600"},
+		}, writer.Header())
+	})
+
+	t.Run("preflight of disallowed origin", func(t *testing.T) {
+		writer := serve(http.MethodOptions, http.Header{
+			"Origin":                        {"https://evil.com"},
+			"Access-Control-Request-Method": {"POST"},
+		})
+		assert.Equal(t, http.StatusNoContent, writer.Code)
+		assert.Equal(t, "", writer.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("preflight of disallowed header", func(t *testing.T) {
+		writer := serve(http.MethodOptions, http.Header{
+			"Origin":                         {"https://app.example.com"},
+			"Access-Control-Request-Method":  {"POST"},
+			"Access-Control-Request-Headers": {"x-other"},
+		})
+		assert.Equal(t, "", writer.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("preflight of route without policy", func(t *testing.T) {
+		writer := serve(http.MethodOptions, http.Header{
+			"Origin":                        {"https://app.example.com"},
+			"Access-Control-Request-Method": {"DELETE"},
+		})
+		assert.Equal(t, http.StatusNoContent, writer.Code)
+		assert.Equal(t, http.Header{
+			"Allow": {"DELETE, GET, HEAD, OPTIONS, POST"},
+		}, writer.Header())
+	})
+
+	t.Run("actual request", func(t *testing.T) {
+		writer := serve(http.MethodGet, http.Header{"Origin": {"https://app.example.com"}})
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, http.Header{
+			"Content-Type":                     {"application/json; charset=utf-8"},
+			"Vary":                             {"Origin"},
+			"Access-Control-Allow-Origin":      {"https://app.example.com"},
+			"Access-Control-Allow-Credentials": {"true"},
+			"Access-Control-Expose-Headers":    {"X-Request-ID"},
+		}, writer.Header())
+	})
+
+	t.Run("actual request of disallowed origin", func(t *testing.T) {
+		writer := serve(http.MethodGet, http.Header{"Origin": {"https://evil.com"}})
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, http.Header{
+			"Content-Type": {"application/json; charset=utf-8"},
+			"Vary":         {"Origin"},
+		}, writer.Header())
+	})
+
+	t.Run("plain options", func(t *testing.T) {
+		writer := serve(http.MethodOptions, nil)
+		assert.Equal(t, http.Header{
+			"Allow": {"DELETE, GET, HEAD, OPTIONS, POST"},
+		}, writer.Header())
+	})
+}
+
+func TestWithCORS_Group(t *testing.T) {
+	r := NewRouter()
+
+	type groupParams struct {
+		UserID userID `json:"user_id"`
+	}
+	type settingParams struct {
+		UserID userID `json:"user_id"`
+	}
+	users := Group(r, urls.New[groupParams]("/api/users/{user_id}")).WithOptions(WithCORS(CORSOptions{
+		AllowedOrigins: []string{"*"},
+	}))
+
+	APIGet(users, urls.New[settingParams]("/settings"), func(ctx Context, req settingParams) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/users/1/settings", nil)
+	req.Header.Set("Origin", "https://any.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, "*", writer.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET", writer.Header().Get("Access-Control-Allow-Methods"))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/users/1/settings", nil)
+	req.Header.Set("Origin", "https://any.example.com")
+	writer = httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, "*", writer.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "", writer.Header().Get("Vary"))
+}
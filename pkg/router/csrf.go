@@ -0,0 +1,312 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const csrfTokenSize = 32
+
+// CSRFStore keeps the synchronizer tokens on the server side, e.g. in the session of the user
+type CSRFStore interface {
+	// LoadToken returns the token of the session of the request, false if none
+	LoadToken(request *http.Request) (string, bool)
+	// SaveToken keeps the new token of the session of the request
+	SaveToken(writer http.ResponseWriter, request *http.Request, token string) error
+}
+
+// CSRFOptions configures the cross-site request forgery protection of the routes
+type CSRFOptions struct {
+	// Store keeps the tokens with the synchronizer token pattern,
+	// the tokens are kept in a cookie (double-submit cookie pattern) if nil
+	Store CSRFStore
+
+	// CookieName is the name of the cookie of the double-submit pattern, "_csrf" if empty
+	CookieName   string
+	CookiePath   string
+	CookieDomain string
+	// CookieMaxAge is the lifetime of the cookie, the cookie lasts for the browser session if zero
+	CookieMaxAge time.Duration
+	Secure       bool
+	// SameSite is http.SameSiteLaxMode if zero
+	SameSite http.SameSite
+
+	// FieldName is the form field of the token, "csrf_token" if empty
+	FieldName string
+	// HeaderName is the header of the token for the scripts, "X-CSRF-Token" if empty
+	HeaderName string
+
+	// ExemptBearerAuth skips the verification of the api requests authenticated with an Authorization: Bearer header,
+	// which is never sent automatically by the browsers. The html routes are always verified
+	ExemptBearerAuth bool
+}
+
+type csrfProtection struct {
+	opts CSRFOptions
+}
+
+// WithCSRF issues a token for every request of the routes and verifies it for the requests with unsafe methods.
+// The token is submitted in the form field or the header, the failures are 403 errors.
+func WithCSRF(opts CSRFOptions) Option {
+	if len(opts.CookieName) == 0 {
+		opts.CookieName = "_csrf"
+	}
+	if len(opts.CookiePath) == 0 {
+		opts.CookiePath = "/"
+	}
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+	if len(opts.FieldName) == 0 {
+		opts.FieldName = "csrf_token"
+	}
+	if len(opts.HeaderName) == 0 {
+		opts.HeaderName = "X-CSRF-Token"
+	}
+
+	protection := &csrfProtection{opts: opts}
+	return func(conf *routerConfig) {
+		conf.csrf = protection
+	}
+}
+
+// WithoutCSRF disables the CSRF protection, e.g. for a webhook route of a router using WithCSRF
+func WithoutCSRF() Option {
+	return func(conf *routerConfig) {
+		conf.csrf = nil
+	}
+}
+
+// ErrCSRF is the error of the requests failing the CSRF verification
+var ErrCSRF = NewError(http.StatusForbidden, "csrf_failed", "invalid csrf token")
+
+type csrfKey struct{}
+
+type csrfValue struct {
+	token     []byte
+	fieldName string
+	// issued is true if the token was created for this request, so the request could not submit it
+	issued bool
+}
+
+// issueCSRF adds the token of the request to its context, a new token is created if the request has none
+func (r *Router) issueCSRF(writer http.ResponseWriter, request *http.Request) (*http.Request, error) {
+	p := r.config.csrf
+	if p == nil {
+		return request, nil
+	}
+
+	token, ok := p.loadToken(request)
+	issued := false
+	if !ok {
+		token = make([]byte, csrfTokenSize)
+		_, _ = rand.Read(token)
+		if err := p.saveToken(writer, request, token); err != nil {
+			return request, err
+		}
+		issued = true
+	}
+
+	value := &csrfValue{token: token, fieldName: p.opts.FieldName, issued: issued}
+	return request.WithContext(context.WithValue(request.Context(), csrfKey{}, value)), nil
+}
+
+func (p *csrfProtection) loadToken(request *http.Request) ([]byte, bool) {
+	var encoded string
+	if p.opts.Store != nil {
+		token, ok := p.opts.Store.LoadToken(request)
+		if !ok {
+			return nil, false
+		}
+		encoded = token
+	} else {
+		cookie, err := request.Cookie(p.opts.CookieName)
+		if err != nil {
+			return nil, false
+		}
+		encoded = cookie.Value
+	}
+
+	token, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(token) != csrfTokenSize {
+		return nil, false
+	}
+	return token, true
+}
+
+func (p *csrfProtection) saveToken(writer http.ResponseWriter, request *http.Request, token []byte) error {
+	encoded := base64.RawURLEncoding.EncodeToString(token)
+	if p.opts.Store != nil {
+		return p.opts.Store.SaveToken(writer, request, encoded)
+	}
+
+	cookie := &http.Cookie{
+		Name:     p.opts.CookieName,
+		Value:    encoded,
+		Path:     p.opts.CookiePath,
+		Domain:   p.opts.CookieDomain,
+		Secure:   p.opts.Secure,
+		HttpOnly: true,
+		SameSite: p.opts.SameSite,
+	}
+	if p.opts.CookieMaxAge > 0 {
+		cookie.MaxAge = int(p.opts.CookieMaxAge.Seconds())
+	}
+	http.SetCookie(writer, cookie)
+	return nil
+}
+
+// verifyCSRF checks the token submitted with a request having an unsafe method,
+// it is called before the decoding of the body, so that the forged requests are never parsed
+func (r *Router) verifyCSRF(request *http.Request, info RouteInfo) error {
+	p := r.config.csrf
+	if p == nil {
+		return nil
+	}
+
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return nil
+	}
+
+	if p.opts.ExemptBearerAuth && info.Kind == RouteKindAPI {
+		scheme, _, _ := strings.Cut(request.Header.Get("Authorization"), " ")
+		if strings.EqualFold(scheme, "Bearer") {
+			return nil
+		}
+	}
+
+	value, ok := request.Context().Value(csrfKey{}).(*csrfValue)
+	if !ok || value.issued {
+		return ErrCSRF.WithDetails(map[string]any{"reason": "missing token"})
+	}
+
+	submitted := request.Header.Get(p.opts.HeaderName)
+	if len(submitted) == 0 && info.FormBody {
+		submitted = peekFormToken(request, p.opts.FieldName)
+	}
+	if len(submitted) == 0 {
+		return ErrCSRF.WithDetails(map[string]any{"reason": "token not submitted"})
+	}
+
+	token, ok := unmaskCSRFToken(submitted)
+	if !ok || subtle.ConstantTimeCompare(token, value.token) != 1 {
+		return ErrCSRF.WithDetails(map[string]any{"reason": "token mismatch"})
+	}
+	return nil
+}
+
+// csrfFormPeekSize is the size of the beginning of a form body that is read to find the token field
+const csrfFormPeekSize = 64 << 10
+
+// peekFormToken returns the token field found at the beginning of a form body, the body is then restored for its decoding.
+// In the multipart forms the field must precede the file parts, as the csrfField of the templates does.
+func peekFormToken(request *http.Request, field string) string {
+	if request.Body == nil || request.Body == http.NoBody || len(request.Header.Get("Content-Encoding")) > 0 {
+		return ""
+	}
+	mediaType, params, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" && mediaType != "multipart/form-data" {
+		return ""
+	}
+
+	var peeked bytes.Buffer
+	reader := io.TeeReader(io.LimitReader(request.Body, csrfFormPeekSize), &peeked)
+	defer func() {
+		request.Body = decompressedBody{
+			Reader: io.MultiReader(&peeked, request.Body),
+			body:   request.Body,
+		}
+	}()
+
+	if mediaType == "application/x-www-form-urlencoded" {
+		data, _ := io.ReadAll(reader)
+		if len(data) == csrfFormPeekSize {
+			// the last field may be truncated
+			data = data[:max(bytes.LastIndexByte(data, '&'), 0)]
+		}
+		values, _ := url.ParseQuery(string(data))
+		return values.Get(field)
+	}
+
+	multipartReader := multipart.NewReader(reader, params["boundary"])
+	for {
+		part, err := multipartReader.NextPart()
+		if err != nil || len(part.FileName()) > 0 {
+			return ""
+		}
+		if part.FormName() == field {
+			data, _ := io.ReadAll(io.LimitReader(part, int64(base64.RawURLEncoding.EncodedLen(2*csrfTokenSize))+1))
+			return string(data)
+		}
+	}
+}
+
+// maskCSRFToken returns a different encoding of the token for each call,
+// so that the token can not be guessed from compressed responses (BREACH)
+func maskCSRFToken(token []byte) string {
+	masked := make([]byte, 2*len(token))
+	otp := masked[:len(token)]
+	_, _ = rand.Read(otp)
+	for i, b := range token {
+		masked[len(token)+i] = b ^ otp[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+func unmaskCSRFToken(masked string) ([]byte, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(masked)
+	if err != nil || len(data) != 2*csrfTokenSize {
+		return nil, false
+	}
+	token := make([]byte, csrfTokenSize)
+	for i := range token {
+		token[i] = data[i] ^ data[csrfTokenSize+i]
+	}
+	return token, true
+}
+
+// CSRFToken returns the token to submit with the forms and the requests of the scripts,
+// an empty string if the route has no CSRF protection
+func CSRFToken(ctx context.Context) string {
+	value, ok := ctx.Value(csrfKey{}).(*csrfValue)
+	if !ok {
+		return ""
+	}
+	return maskCSRFToken(value.token)
+}
+
+// CSRFField returns the hidden input of the token to add to the forms,
+// in the multipart forms it must precede the file inputs since only the beginning of the body is read to find it
+func CSRFField(ctx context.Context) template.HTML {
+	value, ok := ctx.Value(csrfKey{}).(*csrfValue)
+	if !ok {
+		return ""
+	}
+	return template.HTML(fmt.Sprintf(
+		`<input type="hidden" name="%s" value="%s">`,
+		template.HTMLEscapeString(value.fieldName), maskCSRFToken(value.token),
+	))
+}
+
+// CSRFToken returns the token of the request, see WithCSRF
+func (c Context) CSRFToken() string {
+	return CSRFToken(c.Context())
+}
+
+// CSRFField returns the hidden input of the token of the request, see CSRFField
+func (c Context) CSRFField() template.HTML {
+	return CSRFField(c.Context())
+}
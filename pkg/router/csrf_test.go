@@ -0,0 +1,316 @@
+package router
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"html/template"
+	"learn-gin/pkg/urls"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newCSRFRouter(opts CSRFOptions) *Router {
+	r := NewRouter().WithOptions(WithCSRF(opts))
+
+	HTMLGet(r, userPath, func(ctx Context, req userFormRequest) (template.HTML, error) {
+		return template.HTML(ctx.CSRFToken()), nil
+	})
+	HTMLPost(r, userPath, func(ctx Context, req userFormRequest) (template.HTML, error) {
+		return template.HTML("<div>Saved " + req.Body + "</div>"), nil
+	})
+	return r
+}
+
+func postForm(r *Router, form url.Values, header http.Header, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+	return writer
+}
+
+func getCSRFToken(r *Router, cookies ...*http.Cookie) (string, []*http.Cookie) {
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+	return writer.Body.String(), writer.Result().Cookies()
+}
+
+func TestWithCSRF_Double_Submit_Cookie(t *testing.T) {
+	r := newCSRFRouter(CSRFOptions{Secure: true})
+
+	token, cookies := getCSRFToken(r)
+	assert.Equal(t, 1, len(cookies))
+	cookie := cookies[0]
+	assert.Equal(t, "_csrf", cookie.Name)
+	assert.Equal(t, "/", cookie.Path)
+	assert.Equal(t, true, cookie.HttpOnly)
+	assert.Equal(t, true, cookie.Secure)
+	assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+
+	t.Run("token is masked", func(t *testing.T) {
+		otherToken, otherCookies := getCSRFToken(r, cookie)
+		assert.Equal(t, 0, len(otherCookies))
+		assert.NotEqual(t, token, otherToken)
+
+		writer := postForm(r, url.Values{"body": {"B1"}, "csrf_token": {otherToken}}, nil, cookie)
+		assert.Equal(t, http.StatusOK, writer.Code)
+	})
+
+	t.Run("form field", func(t *testing.T) {
+		writer := postForm(r, url.Values{"body": {"B1"}, "csrf_token": {token}}, nil, cookie)
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, "<div>Saved B1</div>", writer.Body.String())
+	})
+
+	t.Run("header", func(t *testing.T) {
+		writer := postForm(r, url.Values{"body": {"B1"}}, http.Header{"X-Csrf-Token": {token}}, cookie)
+		assert.Equal(t, http.StatusOK, writer.Code)
+	})
+
+	t.Run("missing cookie", func(t *testing.T) {
+		writer := postForm(r, url.Values{"body": {"B1"}, "csrf_token": {token}}, nil)
+		assert.Equal(t, http.StatusForbidden, writer.Code)
+		assert.Equal(t, "text/html; charset=utf-8", writer.Header().Get("Content-Type"))
+		assert.Equal(t, true, strings.Contains(writer.Body.String(), "<title>403 Forbidden</title>"))
+		assert.Equal(t, 1, len(writer.Result().Cookies()))
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		writer := postForm(r, url.Values{"body": {"B1"}}, http.Header{"Accept": {"application/json"}}, cookie)
+		assert.Equal(t, http.StatusForbidden, writer.Code)
+		assert.Equal(t,
+			`{"error":"invalid csrf token","code":"csrf_failed","details":{"reason":"token not submitted"}}`+"\n",
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("token of other cookie", func(t *testing.T) {
+		_, otherCookies := getCSRFToken(r)
+		writer := postForm(r, url.Values{"csrf_token": {token}}, http.Header{"Accept": {"application/json"}}, otherCookies[0])
+		assert.Equal(t, http.StatusForbidden, writer.Code)
+		assert.Equal(t,
+			`{"error":"invalid csrf token","code":"csrf_failed","details":{"reason":"token mismatch"}}`+"\n",
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		writer := postForm(r, url.Values{"csrf_token": {"abc"}}, nil, cookie)
+		assert.Equal(t, http.StatusForbidden, writer.Code)
+	})
+}
+
+type testCSRFStore struct {
+	tokens map[string]string
+}
+
+func (s *testCSRFStore) LoadToken(request *http.Request) (string, bool) {
+	cookie, err := request.Cookie("session")
+	if err != nil {
+		return "", false
+	}
+	token, ok := s.tokens[cookie.Value]
+	return token, ok
+}
+
+func (s *testCSRFStore) SaveToken(writer http.ResponseWriter, request *http.Request, token string) error {
+	s.tokens["s1"] = token
+	http.SetCookie(writer, &http.Cookie{Name: "session", Value: "s1"})
+	return nil
+}
+
+func TestWithCSRF_Synchronizer_Token(t *testing.T) {
+	store := &testCSRFStore{tokens: map[string]string{}}
+	r := newCSRFRouter(CSRFOptions{Store: store, FieldName: "_token"})
+
+	token, cookies := getCSRFToken(r)
+	assert.Equal(t, 1, len(cookies))
+	assert.Equal(t, "session", cookies[0].Name)
+	assert.Equal(t, 1, len(store.tokens))
+
+	writer := postForm(r, url.Values{"_token": {token}}, nil, cookies[0])
+	assert.Equal(t, http.StatusOK, writer.Code)
+
+	writer = postForm(r, url.Values{"csrf_token": {token}}, nil, cookies[0])
+	assert.Equal(t, http.StatusForbidden, writer.Code)
+
+	writer = postForm(r, url.Values{"_token": {token}}, nil, &http.Cookie{Name: "session", Value: "s2"})
+	assert.Equal(t, http.StatusForbidden, writer.Code)
+}
+
+func TestWithCSRF_API(t *testing.T) {
+	r := NewRouter().WithOptions(WithCSRF(CSRFOptions{ExemptBearerAuth: true}))
+
+	APIPost(r, loginPath, func(ctx Context, req orderRequest) (userGetResponse, error) {
+		return userGetResponse{UserID: req.UserID}, nil
+	})
+	APIPut(r, loginPath, func(ctx Context, req orderRequest) (userGetResponse, error) {
+		return userGetResponse{UserID: req.UserID}, nil
+	}, WithoutCSRF())
+	APIDelete(r, urls.New[loginParams]("/api/users/{user_id}/sessions"), func(ctx Context, req orderRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+	HTMLPost(r, urls.New[loginParams]("/users/{user_id}/orders"), func(ctx Context, req orderRequest) (template.HTML, error) {
+		return "<div>ordered</div>", nil
+	})
+
+	serve := func(method string, path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"note":"n1"}`))
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+		return writer
+	}
+
+	t.Run("bearer auth", func(t *testing.T) {
+		writer := serve(http.MethodPost, "/api/users/123", http.Header{"Authorization": {"Bearer abc"}})
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, `{"user_id":123,"username":""}`+"\n", writer.Body.String())
+	})
+
+	t.Run("bearer auth on html route", func(t *testing.T) {
+		writer := serve(http.MethodPost, "/users/123/orders", http.Header{"Authorization": {"Bearer abc"}})
+		assert.Equal(t, http.StatusForbidden, writer.Code)
+	})
+
+	t.Run("cookie auth", func(t *testing.T) {
+		writer := serve(http.MethodPost, "/api/users/123", nil)
+		assert.Equal(t, http.StatusForbidden, writer.Code)
+		assert.Equal(t,
+			`{"error":"invalid csrf token","code":"csrf_failed","details":{"reason":"missing token"}}`+"\n",
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("basic auth", func(t *testing.T) {
+		writer := serve(http.MethodDelete, "/api/users/123/sessions", http.Header{"Authorization": {"Basic YTpi"}})
+		assert.Equal(t, http.StatusForbidden, writer.Code)
+	})
+
+	t.Run("without csrf", func(t *testing.T) {
+		writer := serve(http.MethodPut, "/api/users/123", nil)
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, 0, len(writer.Result().Cookies()))
+	})
+}
+
+func TestCSRFField(t *testing.T) {
+	r := NewRouter().WithOptions(WithCSRF(CSRFOptions{FieldName: `a"b`}))
+
+	var field template.HTML
+	HTMLGet(r, userPath, func(ctx Context, req userFormRequest) (template.HTML, error) {
+		field = CSRFField(ctx.Context())
+		return "", nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	r.Mux().ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, true, strings.HasPrefix(string(field), `<input type="hidden" name="a&#34;b" value="`))
+	assert.Equal(t, template.HTML(""), CSRFField(req.Context()))
+	assert.Equal(t, "", CSRFToken(req.Context()))
+}
+
+func TestWithCSRF_Form_Token_Before_Body_Decoding(t *testing.T) {
+	r := NewRouter().WithOptions(WithCSRF(CSRFOptions{}))
+
+	called := false
+	HTMLGet(r, userPath, func(ctx Context, req userFormRequest) (template.HTML, error) {
+		return template.HTML(ctx.CSRFToken()), nil
+	})
+	HTMLPost(r, userPath, func(ctx Context, req uploadRequest) (template.HTML, error) {
+		called = true
+		return template.HTML("<div>Uploaded " + req.Title + "</div>"), nil
+	})
+	HTMLPut(r, userPath, func(ctx Context, req userFormRequest) (template.HTML, error) {
+		return template.HTML("<div>Saved " + strconv.Itoa(len(req.Body)) + "</div>"), nil
+	})
+
+	token, cookies := getCSRFToken(r)
+	cookie := cookies[0]
+
+	postMultipart := func(body *bytes.Buffer, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/users/123", body)
+		req.Header.Set("Content-Type", contentType)
+		req.AddCookie(cookie)
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+		return writer
+	}
+
+	t.Run("multipart", func(t *testing.T) {
+		called = false
+		body, contentType := newMultipartBody(t,
+			map[string]string{"csrf_token": token, "title": "T1"},
+			map[string][]string{"avatar": {"avatar content"}},
+		)
+		writer := postMultipart(body, contentType)
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, "<div>Uploaded T1</div>", writer.Body.String())
+		assert.Equal(t, true, called)
+	})
+
+	t.Run("multipart without token", func(t *testing.T) {
+		called = false
+		body, contentType := newMultipartBody(t,
+			map[string]string{"title": "T1"},
+			map[string][]string{"avatar": {"avatar content"}},
+		)
+		writer := postMultipart(body, contentType)
+		assert.Equal(t, http.StatusForbidden, writer.Code)
+		assert.Equal(t, false, called)
+	})
+
+	t.Run("multipart token after files", func(t *testing.T) {
+		called = false
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		part, err := w.CreateFormFile("avatar", "avatar.txt")
+		assert.Equal(t, nil, err)
+		_, _ = part.Write([]byte("avatar content"))
+		assert.Equal(t, nil, w.WriteField("csrf_token", token))
+		assert.Equal(t, nil, w.Close())
+
+		writer := postMultipart(&body, w.FormDataContentType())
+		assert.Equal(t, http.StatusForbidden, writer.Code)
+		assert.Equal(t, false, called)
+	})
+
+	t.Run("large form", func(t *testing.T) {
+		large := strings.Repeat("x", 2*csrfFormPeekSize)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/users/123",
+			strings.NewReader(url.Values{"csrf_token": {token}}.Encode()+"&body="+large))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookie)
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, "<div>Saved "+strconv.Itoa(len(large))+"</div>", writer.Body.String())
+
+		req = httptest.NewRequest(http.MethodPut, "/api/users/123",
+			strings.NewReader("body="+large+"&"+url.Values{"csrf_token": {token}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookie)
+		writer = httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+		assert.Equal(t, http.StatusForbidden, writer.Code)
+	})
+}
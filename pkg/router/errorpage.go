@@ -0,0 +1,237 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"html/template"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// ErrorTemplate renders the error page of html routes
+type ErrorTemplate = Template
+
+// ErrorPageData is the data passed to the error page templates
+type ErrorPageData struct {
+	Status     int
+	StatusText string
+	Code       string
+	Message    string
+	Details    map[string]any
+	Fields     []FieldError
+	RequestID  string
+
+	// Debug is only set when the dev error pages are enabled
+	Debug *ErrorDebugInfo
+}
+
+// ErrorDebugInfo contains the details shown by the dev error page
+type ErrorDebugInfo struct {
+	Error   string
+	Chain   []string
+	Stack   string
+	Method  string
+	URL     string
+	Route   string
+	Headers []ErrorDebugHeader
+}
+
+type ErrorDebugHeader struct {
+	Name  string
+	Value string
+}
+
+// WithErrorPage sets the template rendering the errors of html routes matching the key.
+// The key is an error code (e.g. "not_found"), a status (e.g. "404") or a status class ("4xx", "5xx").
+// The templates are looked up in that order, with a builtin page used when none matches.
+func WithErrorPage(key string, tmpl ErrorTemplate) Option {
+	return func(conf *routerConfig) {
+		conf.errorPages = maps.Clone(conf.errorPages)
+		if conf.errorPages == nil {
+			conf.errorPages = map[string]ErrorTemplate{}
+		}
+		conf.errorPages[key] = tmpl
+	}
+}
+
+// WithDevErrorPages renders the errors of html routes with a page showing the error chain,
+// the stack and the request details, it must not be enabled in production
+func WithDevErrorPages() Option {
+	return func(conf *routerConfig) {
+		conf.devErrorPages = true
+	}
+}
+
+func (r *Router) errorPageTemplate(status int, code string) ErrorTemplate {
+	if r.config.devErrorPages {
+		return devErrorTemplate
+	}
+
+	keys := []string{
+		code,
+		strconv.Itoa(status),
+		strconv.Itoa(status/100) + "xx",
+	}
+	for _, key := range keys {
+		if tmpl, ok := r.config.errorPages[key]; ok && len(key) > 0 {
+			return tmpl
+		}
+	}
+	return defaultErrorTemplate
+}
+
+func (r *Router) writeErrorPage(ctx Context, err error, status int, errBody ErrorBody) {
+	data := ErrorPageData{
+		Status:     status,
+		StatusText: http.StatusText(status),
+		Code:       errBody.Code,
+		Message:    errBody.Error,
+		Details:    errBody.Details,
+		Fields:     errBody.Fields,
+		RequestID:  ctx.RequestID(),
+	}
+	if r.config.devErrorPages {
+		data.Debug = newErrorDebugInfo(ctx, err)
+	}
+
+	html, renderErr := r.errorPageTemplate(status, errBody.Code).Render(data)
+	if renderErr != nil {
+		html, renderErr = defaultErrorTemplate.Render(data)
+	}
+
+	writer := ctx.writer
+	if renderErr != nil {
+		writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		writer.WriteHeader(status)
+		_, _ = writer.Write([]byte(errBody.Error))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.WriteHeader(status)
+	_, _ = writer.Write([]byte(html))
+}
+
+func newErrorDebugInfo(ctx Context, err error) *ErrorDebugInfo {
+	request := ctx.request
+
+	info := &ErrorDebugInfo{
+		Error:  err.Error(),
+		Stack:  string(ctx.state.stack),
+		Method: request.Method,
+		URL:    request.URL.String(),
+	}
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		info.Chain = append(info.Chain, fmt.Sprintf("%T: %s", e, e.Error()))
+	}
+
+	if routeCtx := chi.RouteContext(request.Context()); routeCtx != nil {
+		info.Route = routeCtx.RoutePattern()
+	}
+
+	names := make([]string, 0, len(request.Header))
+	for name := range request.Header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		for _, value := range request.Header[name] {
+			if name == "Cookie" || name == "Authorization" {
+				value = "[hidden]"
+			}
+			info.Headers = append(info.Headers, ErrorDebugHeader{Name: name, Value: value})
+		}
+	}
+	return info
+}
+
+type builtinErrorTemplate struct {
+	tmpl *template.Template
+}
+
+func (t builtinErrorTemplate) Render(data any) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+var defaultErrorTemplate = builtinErrorTemplate{
+	tmpl: template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Status}} {{.StatusText}}</title>
+</head>
+<body>
+<h1>{{.Status}} {{.StatusText}}</h1>
+<p>{{.Message}}</p>
+{{- if .Fields}}
+<ul>
+{{- range .Fields}}
+<li>{{.Field}}: {{.Message}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .RequestID}}
+<p><small>Request ID: {{.RequestID}}</small></p>
+{{- end}}
+</body>
+</html>
+`)),
+}
+
+var devErrorTemplate = builtinErrorTemplate{
+	tmpl: template.Must(template.New("dev_error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Status}} {{.StatusText}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+pre { background: #f4f4f4; padding: 1em; overflow: auto; }
+td { padding: 0.2em 1em 0.2em 0; vertical-align: top; }
+</style>
+</head>
+<body>
+<h1>{{.Status}} {{.StatusText}}{{if .Code}} <small>({{.Code}})</small>{{end}}</h1>
+<p>{{.Message}}</p>
+{{- with .Debug}}
+<h2>Error</h2>
+<pre>{{range .Chain}}{{.}}
+{{end}}</pre>
+{{- if .Stack}}
+<h2>Stack</h2>
+<pre>{{.Stack}}</pre>
+{{- end}}
+<h2>Request</h2>
+<table>
+<tr><td>Method</td><td>{{.Method}}</td></tr>
+<tr><td>URL</td><td>{{.URL}}</td></tr>
+<tr><td>Route</td><td>{{.Route}}</td></tr>
+{{- range .Headers}}
+<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Fields}}
+<h2>Fields</h2>
+<ul>
+{{- range .Fields}}
+<li>{{.Field}}: {{.Message}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .RequestID}}
+<p><small>Request ID: {{.RequestID}}</small></p>
+{{- end}}
+</body>
+</html>
+`)),
+}
@@ -0,0 +1,143 @@
+package router
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func loadTestTemplate(text string) ErrorTemplate {
+	return builtinErrorTemplate{tmpl: template.Must(template.New("test").Parse(text))}
+}
+
+func TestHTMLGet_Error_Page(t *testing.T) {
+	notFoundPage := loadTestTemplate(`<p>{{.Code}}: {{.Message}} ({{.RequestID}})</p>`)
+	clientErrorPage := loadTestTemplate(`<p>client error {{.Status}}</p>`)
+	serverErrorPage := loadTestTemplate(`<p>server error {{.Status}} {{.StatusText}}</p>`)
+
+	r := NewRouter().WithOptions(
+		WithErrorPage("not_found", notFoundPage),
+		WithErrorPage("4xx", clientErrorPage),
+		WithErrorPage("5xx", serverErrorPage),
+	)
+
+	HTMLGet(r, userPath, func(ctx Context, req userGetRequest) (template.HTML, error) {
+		switch req.UserID {
+		case 404:
+			return "", NotFound("user not found")
+		case 409:
+			return "", Conflict("user already exists")
+		default:
+			return "", errors.New("database error")
+		}
+	})
+
+	t.Run("by code", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/users/404", nil)
+		req.Header.Set("X-Request-ID", "req01")
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+
+		// the X-Request-ID header of the client is only used with WithRequestID
+		assert.Equal(t, http.StatusNotFound, writer.Code)
+		assert.Equal(t, "<p>not_found: user not found ()</p>", writer.Body.String())
+		assert.Equal(t, http.Header{
+			"Content-Type": []string{"text/html; charset=utf-8"},
+		}, writer.Header())
+	})
+
+	t.Run("by status class", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/users/409", nil)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+
+		assert.Equal(t, http.StatusConflict, writer.Code)
+		assert.Equal(t, "<p>client error 409</p>", writer.Body.String())
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/users/500", nil)
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+
+		assert.Equal(t, http.StatusInternalServerError, writer.Code)
+		assert.Equal(t, "<p>server error 500 Internal Server Error</p>", writer.Body.String())
+	})
+
+	t.Run("json fallback", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/users/404", nil)
+		req.Header.Set("Accept", "application/json")
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+
+		assert.Equal(t, http.StatusNotFound, writer.Code)
+		assert.Equal(t, `{"error":"user not found","code":"not_found"}`+"\n", writer.Body.String())
+		assert.Equal(t, http.Header{
+			"Content-Type": []string{"application/json; charset=utf-8"},
+		}, writer.Header())
+	})
+}
+
+func TestHTMLGet_Default_Error_Page(t *testing.T) {
+	r := NewRouter()
+
+	HTMLGet(r, userPath, func(ctx Context, req userGetRequest) (template.HTML, error) {
+		return "", NotFound("<b>user</b> not found")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusNotFound, writer.Code)
+	assert.Contains(t, writer.Body.String(), "<h1>404 Not Found</h1>")
+	assert.Contains(t, writer.Body.String(), "<p>&lt;b&gt;user&lt;/b&gt; not found</p>")
+}
+
+func TestHTMLGet_Dev_Error_Page(t *testing.T) {
+	r := NewRouter().WithOptions(WithDevErrorPages())
+
+	HTMLGet(r, userPath, func(ctx Context, req userGetRequest) (template.HTML, error) {
+		return "", Internal(errors.New("connection refused"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123?search=abc", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	body := writer.Body.String()
+	assert.Equal(t, http.StatusInternalServerError, writer.Code)
+	assert.Contains(t, body, "*router.Error: internal server error: connection refused")
+	assert.Contains(t, body, "*errors.errorString: connection refused")
+	assert.Contains(t, body, "<tr><td>URL</td><td>/api/users/123?search=abc</td></tr>")
+	assert.Contains(t, body, "<tr><td>Route</td><td>/api/users/{user_id}</td></tr>")
+	assert.Contains(t, body, "<tr><td>Authorization</td><td>[hidden]</td></tr>")
+	assert.False(t, strings.Contains(body, "secret"))
+}
+
+func TestNegotiateMediaType(t *testing.T) {
+	newReq := func(accept string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if len(accept) > 0 {
+			req.Header.Set("Accept", accept)
+		}
+		return req
+	}
+
+	assert.Equal(t, "text/html", negotiateMediaType(newReq(""), "text/html", "application/json"))
+	assert.Equal(t, "text/html", negotiateMediaType(newReq("*/*"), "text/html", "application/json"))
+	assert.Equal(t, "application/json", negotiateMediaType(newReq("application/json"), "text/html", "application/json"))
+	assert.Equal(t, "application/json",
+		negotiateMediaType(newReq("text/html;q=0.5, application/*"), "text/html", "application/json"))
+	assert.Equal(t, "text/html",
+		negotiateMediaType(newReq("text/*, application/json;q=0.9"), "text/html", "application/json"))
+	assert.Equal(t, "", negotiateMediaType(newReq("image/png"), "text/html", "application/json"))
+	assert.Equal(t, "text/html",
+		negotiateMediaType(newReq("application/json;q=0, */*"), "text/html", "application/json"))
+}
@@ -0,0 +1,175 @@
+package router
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// Error is an error with a http status, a stable machine-readable code and a message
+// that is safe to be returned to clients. The Cause is never exposed in responses.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details map[string]any
+	Fields  []FieldError
+	Cause   error
+}
+
+func NewError(status int, code string, message string) *Error {
+	return &Error{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// WithDetails returns a copy of the error with the details merged
+func (e *Error) WithDetails(details map[string]any) *Error {
+	newErr := *e
+	newErr.Details = maps.Clone(e.Details)
+	if newErr.Details == nil {
+		newErr.Details = map[string]any{}
+	}
+	maps.Copy(newErr.Details, details)
+	return &newErr
+}
+
+// WithFields returns a copy of the error with the field errors appended
+func (e *Error) WithFields(fields []FieldError) *Error {
+	newErr := *e
+	newErr.Fields = append(slices.Clone(e.Fields), fields...)
+	return &newErr
+}
+
+// WithCause returns a copy of the error wrapping the cause
+func (e *Error) WithCause(err error) *Error {
+	newErr := *e
+	newErr.Cause = err
+	return &newErr
+}
+
+func BadRequest(message string) *Error {
+	return NewError(http.StatusBadRequest, "bad_request", message)
+}
+
+func Unauthorized(message string) *Error {
+	return NewError(http.StatusUnauthorized, "unauthorized", message)
+}
+
+func Forbidden(message string) *Error {
+	return NewError(http.StatusForbidden, "forbidden", message)
+}
+
+func NotFound(message string) *Error {
+	return NewError(http.StatusNotFound, "not_found", message)
+}
+
+func Conflict(message string) *Error {
+	return NewError(http.StatusConflict, "conflict", message)
+}
+
+func Validation(message string, details map[string]any) *Error {
+	return &Error{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    "validation_failed",
+		Message: message,
+		Details: details,
+	}
+}
+
+func Internal(cause error) *Error {
+	return &Error{
+		Status:  http.StatusInternalServerError,
+		Code:    "internal_error",
+		Message: "internal server error",
+		Cause:   cause,
+	}
+}
+
+// ErrorMapper translates domain errors into an *Error, returns nil when the error is not recognized
+type ErrorMapper = func(err error) *Error
+
+type ErrorBody struct {
+	Error   string         `json:"error"`
+	Code    string         `json:"code,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+	Fields  []FieldError   `json:"fields,omitempty"`
+
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func (r *Router) toRouterError(err error) *Error {
+	var routerErr *Error
+	if errors.As(err, &routerErr) {
+		return routerErr
+	}
+	if r.config.errorMapper != nil {
+		return r.config.errorMapper(err)
+	}
+	return nil
+}
+
+// resolveError computes the response status and body of an error.
+// The default status is used for errors that are not an *Error, unless SetStatusCode was called.
+func (r *Router) resolveError(ctx Context, err error, defaultStatus int) (int, ErrorBody) {
+	status, body := r.resolveErrorBody(ctx, err, defaultStatus)
+	body.RequestID = ctx.RequestID()
+	ctx.state.err = err
+	ctx.state.errCode = body.Code
+
+	span := ctx.Span()
+	if len(body.Code) > 0 {
+		span.SetAttribute("error.code", body.Code)
+	}
+	if status >= http.StatusInternalServerError {
+		span.RecordError(err)
+	}
+	return status, body
+}
+
+func (r *Router) resolveErrorBody(ctx Context, err error, defaultStatus int) (int, ErrorBody) {
+	routerErr := r.toRouterError(err)
+	if routerErr != nil {
+		status := routerErr.Status
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		return status, ErrorBody{
+			Error:   routerErr.Message,
+			Code:    routerErr.Code,
+			Details: routerErr.Details,
+			Fields:  routerErr.Fields,
+		}
+	}
+
+	status := defaultStatus
+	if ctx.state.code >= http.StatusBadRequest {
+		status = ctx.state.code
+	}
+
+	if !r.config.exposeInternalErrors && status >= http.StatusInternalServerError {
+		internalErr := Internal(err)
+		return status, ErrorBody{
+			Error: internalErr.Message,
+			Code:  internalErr.Code,
+		}
+	}
+
+	return status, ErrorBody{
+		Error: err.Error(),
+	}
+}
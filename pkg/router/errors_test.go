@@ -0,0 +1,157 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	t.Run("without cause", func(t *testing.T) {
+		err := NotFound("user not found")
+		assert.Equal(t, "user not found", err.Error())
+		assert.Equal(t, http.StatusNotFound, err.Status)
+		assert.Equal(t, "not_found", err.Code)
+	})
+
+	t.Run("with cause", func(t *testing.T) {
+		cause := errors.New("sql: no rows")
+		err := NotFound("user not found").WithCause(cause)
+		assert.Equal(t, "user not found: sql: no rows", err.Error())
+		assert.Same(t, cause, errors.Unwrap(err))
+	})
+
+	t.Run("with details", func(t *testing.T) {
+		base := Conflict("duplicated")
+		err := base.WithDetails(map[string]any{"field": "username"})
+		assert.Equal(t, map[string]any{"field": "username"}, err.Details)
+		assert.Nil(t, base.Details)
+	})
+
+	t.Run("errors as wrapped", func(t *testing.T) {
+		err := fmt.Errorf("get user: %w", Unauthorized("missing token"))
+
+		var routerErr *Error
+		assert.Equal(t, true, errors.As(err, &routerErr))
+		assert.Equal(t, http.StatusUnauthorized, routerErr.Status)
+	})
+}
+
+func TestAPIGet_With_Router_Error(t *testing.T) {
+	r := NewRouter()
+
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		err := Conflict("user already existed").
+			WithDetails(map[string]any{"user_id": 123}).
+			WithCause(errors.New("duplicate key value"))
+		return userGetResponse{}, fmt.Errorf("wrapped: %w", err)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusConflict, writer.Code)
+	assert.Equal(t,
+		`{"error":"user already existed","code":"conflict","details":{"user_id":123}}`+"\n",
+		writer.Body.String(),
+	)
+}
+
+var errDomainNotFound = errors.New("domain: dataset not found")
+
+func TestAPIGet_With_Error_Mapper(t *testing.T) {
+	r := NewRouter().WithOptions(WithErrorMapper(func(err error) *Error {
+		if errors.Is(err, errDomainNotFound) {
+			return NotFound("dataset not found").WithCause(err)
+		}
+		return nil
+	}))
+
+	var handlerErr error
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		return userGetResponse{}, handlerErr
+	})
+
+	t.Run("mapped", func(t *testing.T) {
+		handlerErr = fmt.Errorf("get dataset: %w", errDomainNotFound)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+
+		assert.Equal(t, http.StatusNotFound, writer.Code)
+		assert.Equal(t, `{"error":"dataset not found","code":"not_found"}`+"\n", writer.Body.String())
+	})
+
+	t.Run("not mapped", func(t *testing.T) {
+		handlerErr = errors.New("some error")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+
+		assert.Equal(t, http.StatusInternalServerError, writer.Code)
+		assert.Equal(t, `{"error":"internal server error","code":"internal_error"}`+"\n", writer.Body.String())
+	})
+}
+
+func TestAPIGet_Hides_Internal_Errors(t *testing.T) {
+	r := NewRouter()
+
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		return userGetResponse{}, errors.New("pq: connection refused to 10.0.0.1")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusInternalServerError, writer.Code)
+	assert.Equal(t, `{"error":"internal server error","code":"internal_error"}`+"\n", writer.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/users/123?age=AB", nil)
+	writer = httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusBadRequest, writer.Code)
+	assert.Equal(t,
+		`{"error":"router: can not parse value 'AB' into field 'Age'"}`+"\n",
+		writer.Body.String(),
+	)
+}
+
+func TestAPIGet_With_Exposed_Internal_Errors(t *testing.T) {
+	r := NewRouter().WithOptions(WithExposedInternalErrors())
+
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		return userGetResponse{}, errors.New("pq: connection refused to 10.0.0.1")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusInternalServerError, writer.Code)
+	assert.Equal(t, `{"error":"pq: connection refused to 10.0.0.1"}`+"\n", writer.Body.String())
+}
+
+func TestHTMLGet_With_Router_Error(t *testing.T) {
+	r := NewRouter()
+
+	HTMLGet(r, userPath, func(ctx Context, req userGetRequest) (template.HTML, error) {
+		return "", NotFound("user not found")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	req.Header.Set("Accept", "application/json")
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusNotFound, writer.Code)
+	assert.Equal(t, `{"error":"user not found","code":"not_found"}`+"\n", writer.Body.String())
+}
@@ -0,0 +1,86 @@
+package router
+
+import (
+	"learn-gin/pkg/urls"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+type routePrefix struct {
+	pattern    string
+	pathParams []string
+	allParams  []string
+	types      []reflect.Type
+}
+
+// routePath is the non-generic form of an urls.Path, joined with the prefix of the router
+type routePath struct {
+	pattern    string
+	pathParams []string
+	allParams  []string
+}
+
+// Group returns a child router whose routes are registered under the prefix.
+// The request types of the child routes must contain all fields of P.
+func Group[P any](r *Router, prefix urls.Path[P]) *Router {
+	newR := *r
+	newR.prefix = routePrefix{
+		pattern:    joinPattern(r.prefix.pattern, prefix.GetPattern()),
+		pathParams: appendParams(r.prefix.pathParams, prefix.GetPathParams()),
+		allParams:  appendParams(r.prefix.allParams, prefix.GetAllParams()),
+		types:      append(slices.Clone(r.prefix.types), reflect.TypeFor[P]()),
+	}
+	return &newR
+}
+
+// Route calls fn with a child router created by Group
+func Route[P any](r *Router, prefix urls.Path[P], fn func(r *Router)) {
+	fn(Group(r, prefix))
+}
+
+// Mount attaches a separately built router under the prefix.
+// Handlers of the sub router only bind the params of their own paths.
+func Mount[P any](r *Router, prefix urls.Path[P], sub *Router) {
+	mountPrefix := Group(r, prefix).prefix
+	r.mux.Mount(mountPrefix.pattern, sub.mux)
+	r.registry.mount(mountPrefix, sub.registry)
+}
+
+func newRoutePath[T any](r *Router, pattern urls.Path[T]) routePath {
+	return routePath{
+		pattern:    joinPattern(r.prefix.pattern, pattern.GetPattern()),
+		pathParams: appendParams(r.prefix.pathParams, pattern.GetPathParams()),
+		allParams:  appendParams(r.prefix.allParams, pattern.GetAllParams()),
+	}
+}
+
+func (r *Router) checkRequestType(req any) {
+	for _, prefixType := range r.prefix.types {
+		urls.CheckIsSubStruct(req, reflect.Zero(prefixType).Interface())
+	}
+}
+
+func joinPattern(prefix string, pattern string) string {
+	if len(prefix) == 0 {
+		return pattern
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	if pattern == "/" {
+		if len(prefix) == 0 {
+			return "/"
+		}
+		return prefix
+	}
+	return prefix + pattern
+}
+
+func appendParams(params []string, newParams []string) []string {
+	result := slices.Clone(params)
+	for _, p := range newParams {
+		if !slices.Contains(result, p) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+package router
+
+import (
+	"github.com/stretchr/testify/assert"
+	"learn-gin/pkg/urls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type datasetParams struct {
+	DatasetID int64 `json:"dataset_id"`
+}
+
+type datasetRowParams struct {
+	RowID int64 `json:"row_id"`
+}
+
+type datasetRowRequest struct {
+	DatasetID int64  `json:"dataset_id"`
+	RowID     int64  `json:"row_id"`
+	Search    string `json:"search"`
+}
+
+var datasetPrefix = urls.New[datasetParams]("/api/datasets/{dataset_id}")
+
+var datasetRowPath = urls.New[datasetRowParams]("/rows/{row_id}")
+
+func TestGroup_Success(t *testing.T) {
+	r := NewRouter()
+
+	var inputReq datasetRowRequest
+	ds := Group(r, datasetPrefix)
+	APIGet(ds, datasetRowPath, func(ctx Context, req datasetRowRequest) (userGetResponse, error) {
+		inputReq = req
+		return userGetResponse{Username: "row"}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/datasets/12/rows/34", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, datasetRowRequest{
+		DatasetID: 12,
+		RowID:     34,
+	}, inputReq)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, `{"user_id":0,"username":"row"}`+"\n", writer.Body.String())
+}
+
+func TestGroup_Root_Path(t *testing.T) {
+	r := NewRouter()
+
+	var inputReq datasetParams
+	ds := Group(r, datasetPrefix)
+	APIGet(ds, urls.NewEmpty("/"), func(ctx Context, req datasetParams) (userGetResponse, error) {
+		inputReq = req
+		return userGetResponse{}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/datasets/12", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, datasetParams{DatasetID: 12}, inputReq)
+}
+
+func TestGroup_Nested_With_Middlewares(t *testing.T) {
+	r := NewRouter()
+
+	var steps []string
+	api := Group(r, urls.NewEmpty("/api"))
+	ds := Group(api, urls.New[datasetParams]("/datasets/{dataset_id}")).WithMiddlewares(
+		func(handler GenericHandler) GenericHandler {
+			return func(ctx Context, req any) (resp any, err error) {
+				steps = append(steps, "dataset")
+				return handler(ctx, req)
+			}
+		},
+	)
+
+	APIGet(ds, datasetRowPath, func(ctx Context, req datasetRowRequest) (userGetResponse, error) {
+		steps = append(steps, "row")
+		return userGetResponse{}, nil
+	})
+	APIGet(api, urls.NewEmpty("/health"), func(ctx Context, req urls.Empty) (userGetResponse, error) {
+		steps = append(steps, "health")
+		return userGetResponse{}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/datasets/12/rows/34", nil)
+	r.Mux().ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	r.Mux().ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, []string{"dataset", "row", "health"}, steps)
+}
+
+func TestGroup_Request_Missing_Prefix_Field(t *testing.T) {
+	r := NewRouter()
+
+	ds := Group(r, datasetPrefix)
+
+	assert.PanicsWithValue(t, "missing field 'DatasetID' in struct 'datasetRowParams'", func() {
+		APIGet(ds, datasetRowPath, func(ctx Context, req datasetRowParams) (userGetResponse, error) {
+			return userGetResponse{}, nil
+		})
+	})
+}
+
+func TestMount(t *testing.T) {
+	sub := NewRouter()
+
+	var inputReq datasetRowParams
+	APIGet(sub, datasetRowPath, func(ctx Context, req datasetRowParams) (userGetResponse, error) {
+		inputReq = req
+		return userGetResponse{Username: "mounted"}, nil
+	})
+
+	r := NewRouter()
+	Mount(r, urls.NewEmpty("/admin"), sub)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/rows/55", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, datasetRowParams{RowID: 55}, inputReq)
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, `{"user_id":0,"username":"mounted"}`+"\n", writer.Body.String())
+}
@@ -5,71 +5,144 @@ import (
 	"html/template"
 	"learn-gin/pkg/urls"
 	"net/http"
+	"reflect"
 )
 
 func HTMLGet[T any, Req any](
 	r *Router, pattern urls.Path[T],
 	handler func(ctx Context, req Req) (template.HTML, error),
+	opts ...Option,
 ) {
-	htmlDoAction(r, r.mux.Get, false, pattern, handler)
+	htmlDoAction(r, http.MethodGet, pattern, handler, opts)
 }
 
 func HTMLPost[T any, Req any](
 	r *Router, pattern urls.Path[T],
 	handler func(ctx Context, req Req) (template.HTML, error),
+	opts ...Option,
 ) {
-	htmlDoAction(r, r.mux.Post, true, pattern, handler)
+	htmlDoAction(r, http.MethodPost, pattern, handler, opts)
+}
+
+func HTMLPut[T any, Req any](
+	r *Router, pattern urls.Path[T],
+	handler func(ctx Context, req Req) (template.HTML, error),
+	opts ...Option,
+) {
+	htmlDoAction(r, http.MethodPut, pattern, handler, opts)
+}
+
+func HTMLPatch[T any, Req any](
+	r *Router, pattern urls.Path[T],
+	handler func(ctx Context, req Req) (template.HTML, error),
+	opts ...Option,
+) {
+	htmlDoAction(r, http.MethodPatch, pattern, handler, opts)
+}
+
+func HTMLDelete[T any, Req any](
+	r *Router, pattern urls.Path[T],
+	handler func(ctx Context, req Req) (template.HTML, error),
+	opts ...Option,
+) {
+	htmlDoAction(r, http.MethodDelete, pattern, handler, opts)
 }
 
 func htmlDoAction[T any, Req any](
 	r *Router,
-	registerFunc func(pattern string, handler http.HandlerFunc),
-	decodeBody bool,
+	method string,
 	pattern urls.Path[T],
 	handler func(ctx Context, req Req) (template.HTML, error),
+	opts []Option,
 ) {
+	r = r.WithOptions(opts...)
+
 	var testPathVal T
 	var testReqVal Req
 	urls.CheckIsSubStruct(testReqVal, testPathVal)
+	r.checkRequestType(testReqVal)
+	checkValidation(testReqVal)
+
+	path := newRoutePath(r, pattern)
+	plan := newBindingPlan(reflect.TypeFor[Req](), path)
+	formPlan := newFormPlan(reflect.TypeFor[Req]())
 
 	genericHandler := func(ctx Context, req any) (resp any, err error) {
 		return handler(ctx, req.(Req))
 	}
 	genericHandler = r.wrapHandler(genericHandler)
 
-	registerFunc(pattern.GetPattern(), func(writer http.ResponseWriter, request *http.Request) {
+	rule := bodyRuleOf(method)
+
+	info := newRouteInfo[Req, template.HTML](RouteKindHTML, method, path, true)
+	r.register(info, func(writer http.ResponseWriter, request *http.Request) {
+		request, csrfErr := r.issueCSRF(writer, request)
 		ctx := NewContext(writer, request)
+		if csrfErr != nil {
+			r.writeHTMLResp(ctx, nil, csrfErr, http.StatusInternalServerError)
+			return
+		}
+
+		if err := r.verifyCSRF(request, info); err != nil {
+			r.writeHTMLResp(ctx, nil, err, http.StatusForbidden)
+			return
+		}
 
 		var req Req
-		if decodeBody {
-			if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
-				r.writeHTMLResp(ctx, "", err, http.StatusBadRequest)
-				return
-			}
+		defer removeMultipartFiles(request)
+		if err := r.decodeBody(request, rule, formPlan, &req); err != nil {
+			r.writeHTMLResp(ctx, nil, err, http.StatusBadRequest)
+			return
+		}
+
+		if err := plan.bind(request, &req); err != nil {
+			r.writeHTMLResp(ctx, nil, err, http.StatusBadRequest)
+			return
 		}
 
-		if err := doAssignParams(ctx, &req, pattern); err != nil {
-			r.writeHTMLResp(ctx, "", err, http.StatusBadRequest)
+		ctx.state.req = req
+
+		if err := validateRequest(&req); err != nil {
+			r.writeHTMLResp(ctx, nil, err, http.StatusUnprocessableEntity)
 			return
 		}
 
 		respBody, err := genericHandler(ctx, req)
-		r.writeHTMLResp(ctx, respBody.(template.HTML), err, http.StatusInternalServerError)
+		r.writeHTMLResp(ctx, respBody, err, http.StatusInternalServerError)
 	})
 }
 
-func (r *Router) writeHTMLResp(ctx Context, respBody template.HTML, err error, status int) {
+func (r *Router) writeHTMLResp(ctx Context, respBody any, err error, status int) {
 	writer := ctx.writer
 
 	if err != nil {
+		status, errBody := r.resolveError(ctx, err, status)
+		if !prefersJSON(ctx.request) {
+			r.writeErrorPage(ctx, err, status, errBody)
+			return
+		}
 		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
 		writer.WriteHeader(status)
-		_ = json.NewEncoder(writer).Encode(ErrorBody{
-			Error: err.Error(),
-		})
+		_ = json.NewEncoder(writer).Encode(errBody)
+		return
+	}
+
+	if responder, ok := responderOf(ctx, respBody); ok {
+		if err := responder.Respond(ctx); err != nil {
+			r.writeHTMLResp(ctx, nil, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	html, _ := respBody.(template.HTML)
+
+	status = ctx.successStatus()
+	if !bodyAllowed(status) {
+		writer.WriteHeader(status)
 		return
 	}
 
 	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
-	_, _ = writer.Write([]byte(respBody))
+	writer.WriteHeader(status)
+	_, _ = writer.Write([]byte(html))
 }
@@ -0,0 +1,361 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// WithMaxBodySize limits the size of the request bodies, a larger body is rejected with a 413 error
+func WithMaxBodySize(maxSize int64) Option {
+	return func(conf *routerConfig) {
+		conf.maxBodySize = maxSize
+	}
+}
+
+// WithDisallowUnknownFields rejects the json bodies having fields that are not in the request type
+func WithDisallowUnknownFields() Option {
+	return func(conf *routerConfig) {
+		conf.disallowUnknownFields = true
+	}
+}
+
+// WithRejectTrailingData rejects the json bodies followed by anything else than white spaces
+func WithRejectTrailingData() Option {
+	return func(conf *routerConfig) {
+		conf.rejectTrailingData = true
+	}
+}
+
+// WithUseNumber decodes the numbers into interface{} fields as json.Number instead of float64
+func WithUseNumber() Option {
+	return func(conf *routerConfig) {
+		conf.useNumber = true
+	}
+}
+
+// WithRequiredContentType rejects the request bodies whose media type is not one of the media types
+// with a 415 error. The form media types are always accepted by the routes decoding forms.
+func WithRequiredContentType(mediaTypes ...string) Option {
+	return func(conf *routerConfig) {
+		conf.contentTypes = slices.Clone(mediaTypes)
+	}
+}
+
+func hasBody(request *http.Request) bool {
+	return request.Body != nil && request.Body != http.NoBody && request.ContentLength != 0
+}
+
+// checkContentType returns a 415 error if the body has not one of the required media types
+func (r *Router) checkContentType(request *http.Request, formEnabled bool) error {
+	if len(r.config.contentTypes) == 0 || !hasBody(request) {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if slices.Contains(r.config.contentTypes, mediaType) {
+		return nil
+	}
+	if formEnabled && (mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data") {
+		return nil
+	}
+
+	message := "missing content type"
+	if len(mediaType) > 0 {
+		message = fmt.Sprintf("unsupported content type '%s'", mediaType)
+	}
+	return NewError(http.StatusUnsupportedMediaType, "unsupported_media_type", message).WithDetails(map[string]any{
+		"accepted": r.config.contentTypes,
+	})
+}
+
+func (r *Router) decodeJSONBody(request *http.Request, rule bodyRule, req any) error {
+	if rule == bodyNone {
+		return nil
+	}
+	if rule == bodyOptional && (request.Body == nil || request.Body == http.NoBody) {
+		return nil
+	}
+
+	var body io.Reader = request.Body
+	data := prefixBuffer{limit: unknownFieldBufferSize}
+	if r.config.disallowUnknownFields {
+		// the beginning of the body is kept to find the path of an unknown field
+		body = io.TeeReader(body, &data)
+	}
+
+	decoder := json.NewDecoder(body)
+	if r.config.disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if r.config.useNumber {
+		decoder.UseNumber()
+	}
+
+	err := decoder.Decode(req)
+	if errors.Is(err, io.EOF) && rule == bodyOptional {
+		return nil
+	}
+	if err != nil {
+		if isUnknownFieldError(err) {
+			_, _ = io.CopyN(io.Discard, body, int64(data.limit-data.Len()))
+		}
+		return jsonBodyError(err, reflect.TypeOf(req).Elem(), data.Bytes())
+	}
+
+	if r.config.rejectTrailingData {
+		if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+			return NewError(http.StatusBadRequest, "invalid_json", "unexpected data after the json body")
+		}
+	}
+	return nil
+}
+
+// unknownFieldBufferSize caps the body kept by WithDisallowUnknownFields,
+// the unknown fields of a larger body are reported without their path
+const unknownFieldBufferSize = 1 << 20
+
+// prefixBuffer keeps the first limit bytes written to it and discards the others
+type prefixBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *prefixBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+// isUnknownFieldError reports whether err is the error of json.Decoder.DisallowUnknownFields.
+// encoding/json has no error type for it, so this depends on the text of its error, `json: unknown field "name"`,
+// pinned by TestIsUnknownFieldError. If the text changes, the error falls back to a 400 without the path of the field.
+func isUnknownFieldError(err error) bool {
+	return strings.HasPrefix(err.Error(), "json: unknown field ")
+}
+
+// jsonBodyError converts the errors of the json decoder to a 400 error naming the path of the invalid field
+func jsonBodyError(err error, typ reflect.Type, data []byte) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return bodyTooLargeError(err)
+	}
+
+	invalidBody := func(message string) *Error {
+		return NewError(http.StatusBadRequest, "invalid_json", message).WithCause(err)
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return NewError(http.StatusBadRequest, "empty_body", "request body is empty")
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return invalidBody("unexpected end of the json body")
+
+	case errors.As(err, &syntaxErr):
+		return invalidBody("invalid json: " + syntaxErr.Error()).WithDetails(map[string]any{
+			"offset": syntaxErr.Offset,
+		})
+
+	case errors.As(err, &typeErr):
+		var segments []string
+		if len(typeErr.Field) > 0 {
+			segments = strings.Split(typeErr.Field, ".")
+		}
+		field, path := jsonFieldPath(typ, segments)
+		return invalidBody("invalid request body").WithFields([]FieldError{{
+			Field:   field,
+			Path:    path,
+			Rule:    "type",
+			Message: fmt.Sprintf("expected %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value),
+		}})
+
+	case isUnknownFieldError(err):
+		segments, ok := findUnknownField(typ, data)
+		if !ok {
+			return invalidBody(err.Error())
+		}
+		field, path := jsonFieldPath(typ, segments)
+		return invalidBody("invalid request body").WithFields([]FieldError{{
+			Field:   field,
+			Path:    path,
+			Rule:    "unknown",
+			Message: "unknown field",
+		}})
+
+	default:
+		// also the unknown fields if the text of their error is not recognized, see isUnknownFieldError
+		return invalidBody(err.Error())
+	}
+}
+
+// jsonFieldPath returns the go field names and the json path of the json keys,
+// formatted as the paths of the validation errors, e.g. Items[1].Count and items[1].count
+func jsonFieldPath(typ reflect.Type, segments []string) (string, string) {
+	var field, path string
+	for _, segment := range segments {
+		for typ != nil && typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+
+		switch {
+		case typ == nil:
+			field = joinFieldName(field, segment)
+			path = joinFieldName(path, segment)
+
+		case typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array:
+			field += "[" + segment + "]"
+			path += "[" + segment + "]"
+			typ = typ.Elem()
+
+		case typ.Kind() == reflect.Map:
+			field = joinFieldName(field, segment)
+			path = joinFieldName(path, segment)
+			typ = typ.Elem()
+
+		case typ.Kind() == reflect.Struct:
+			f, ok := jsonField(typ, segment)
+			if !ok {
+				field = joinFieldName(field, segment)
+				path = joinFieldName(path, segment)
+				typ = nil
+				continue
+			}
+			field = joinFieldName(field, f.Name)
+			path = joinFieldName(path, segment)
+			typ = f.Type
+
+		default:
+			field = joinFieldName(field, segment)
+			path = joinFieldName(path, segment)
+			typ = nil
+		}
+	}
+	return field, path
+}
+
+// jsonField finds the struct field decoded from the json key, with the case-insensitive matching of encoding/json
+func jsonField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	var folded *reflect.StructField
+	for _, f := range reflect.VisibleFields(typ) {
+		if !f.IsExported() || f.Anonymous && f.Type.Kind() == reflect.Struct && len(f.Tag.Get("json")) == 0 {
+			continue
+		}
+		name := computeJsonName(f.Tag.Get("json"))
+		if name == "-" {
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		if name == key {
+			return f, true
+		}
+		if folded == nil && strings.EqualFold(name, key) {
+			folded = &f
+		}
+	}
+	if folded != nil {
+		return *folded, true
+	}
+	return reflect.StructField{}, false
+}
+
+// findUnknownField returns the json keys of the first field of the data that is not in the type
+func findUnknownField(typ reflect.Type, data []byte) ([]string, bool) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		if reflect.PointerTo(typ).Implements(jsonUnmarshalerType) {
+			return nil, false
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, false
+		}
+		for _, key := range sortedKeys(fields) {
+			f, ok := jsonField(typ, key)
+			if !ok {
+				return []string{key}, true
+			}
+			if rest, ok := findUnknownField(f.Type, fields[key]); ok {
+				return append([]string{key}, rest...), true
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, false
+		}
+		for i, item := range items {
+			if rest, ok := findUnknownField(typ.Elem(), item); ok {
+				return append([]string{strconv.Itoa(i)}, rest...), true
+			}
+		}
+
+	case reflect.Map:
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, false
+		}
+		for _, key := range sortedKeys(values) {
+			if rest, ok := findUnknownField(typ.Elem(), values[key]); ok {
+				return append([]string{key}, rest...), true
+			}
+		}
+	}
+	return nil, false
+}
+
+func sortedKeys(values map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+var jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+func jsonTypeName(typ reflect.Type) string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		return "array"
+	case reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return typ.String()
+	}
+}
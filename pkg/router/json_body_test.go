@@ -0,0 +1,253 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type orderItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type orderRequest struct {
+	UserID userID      `json:"user_id"`
+	Note   string      `json:"note"`
+	Items  []orderItem `json:"items"`
+	Extra  any         `json:"extra"`
+}
+
+func serveJSON(r *Router, contentType string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", strings.NewReader(body))
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+	return writer
+}
+
+func TestAPIPost_Strict_JSON(t *testing.T) {
+	r := NewRouter().WithOptions(WithDisallowUnknownFields(), WithRejectTrailingData(), WithUseNumber())
+
+	var inputReq orderRequest
+	APIPost(r, loginPath, func(ctx Context, req orderRequest) (userGetResponse, error) {
+		inputReq = req
+		return userGetResponse{}, nil
+	})
+
+	t.Run("success", func(t *testing.T) {
+		writer := serveJSON(r, "", `{"note":"n1","items":[{"name":"a","count":2}],"extra":12345678901234567890}`+"\n")
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, orderRequest{
+			UserID: 123,
+			Note:   "n1",
+			Items:  []orderItem{{Name: "a", Count: 2}},
+			Extra:  json.Number("12345678901234567890"),
+		}, inputReq)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		writer := serveJSON(r, "", `{"note":"n1","other":1}`)
+		assert.Equal(t, http.StatusBadRequest, writer.Code)
+		assert.Equal(t,
+			`{"error":"invalid request body","code":"invalid_json","fields":[`+
+				`{"field":"other","path":"other","rule":"unknown","message":"unknown field"}]}`+"\n",
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("nested unknown field", func(t *testing.T) {
+		writer := serveJSON(r, "", `{"items":[{"name":"a"},{"name":"b","price":2}]}`)
+		assert.Equal(t, http.StatusBadRequest, writer.Code)
+		assert.Equal(t,
+			`{"error":"invalid request body","code":"invalid_json","fields":[`+
+				`{"field":"Items[1].price","path":"items[1].price","rule":"unknown","message":"unknown field"}]}`+"\n",
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("unknown field in large body", func(t *testing.T) {
+		note := strings.Repeat("x", unknownFieldBufferSize)
+		writer := serveJSON(r, "", `{"other":1,"note":"`+note+`"}`)
+		assert.Equal(t, http.StatusBadRequest, writer.Code)
+		assert.Equal(t,
+			`{"error":"json: unknown field \"other\"","code":"invalid_json"}`+"\n",
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("trailing data", func(t *testing.T) {
+		writer := serveJSON(r, "", `{"note":"n1"} {"note":"n2"}`)
+		assert.Equal(t, http.StatusBadRequest, writer.Code)
+		assert.Equal(t,
+			`{"error":"unexpected data after the json body","code":"invalid_json"}`+"\n",
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		writer := serveJSON(r, "", `{"note" "n1"}`)
+		assert.Equal(t, http.StatusBadRequest, writer.Code)
+
+		var body ErrorBody
+		assert.Equal(t, nil, json.Unmarshal(writer.Body.Bytes(), &body))
+		assert.Equal(t, "invalid json: invalid character '\"' after object key", body.Error)
+		assert.Equal(t, "invalid_json", body.Code)
+		assert.NotNil(t, body.Details["offset"])
+	})
+
+	t.Run("type error in slice", func(t *testing.T) {
+		writer := serveJSON(r, "", `{"items":[{"name":"a"},{"count":"two"}]}`)
+		assert.Equal(t, http.StatusBadRequest, writer.Code)
+
+		var body ErrorBody
+		assert.Equal(t, nil, json.Unmarshal(writer.Body.Bytes(), &body))
+		assert.Equal(t, []FieldError{{
+			Field:   "Items[1].Count",
+			Path:    "items[1].count",
+			Rule:    "type",
+			Message: "expected number, got string",
+		}}, body.Fields)
+	})
+}
+
+func TestIsUnknownFieldError(t *testing.T) {
+	var req orderRequest
+	decoder := json.NewDecoder(strings.NewReader(`{"other":1}`))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&req)
+	assert.Equal(t, `json: unknown field "other"`, err.Error())
+	assert.Equal(t, true, isUnknownFieldError(err))
+
+	err = json.Unmarshal([]byte(`{"note":1}`), &req)
+	assert.Equal(t, false, isUnknownFieldError(err))
+}
+
+func TestJSONBodyError_Unrecognized_Unknown_Field_Text(t *testing.T) {
+	err := jsonBodyError(errors.New(`json: unknown key "other"`), reflect.TypeFor[orderRequest](), []byte(`{"other":1}`))
+
+	var routerErr *Error
+	assert.Equal(t, true, errors.As(err, &routerErr))
+	assert.Equal(t, http.StatusBadRequest, routerErr.Status)
+	assert.Equal(t, "invalid_json", routerErr.Code)
+	assert.Equal(t, `json: unknown key "other"`, routerErr.Message)
+	assert.Equal(t, 0, len(routerErr.Fields))
+}
+
+func TestPrefixBuffer(t *testing.T) {
+	buf := prefixBuffer{limit: 5}
+	n, err := buf.Write([]byte("abc"))
+	assert.Equal(t, 3, n)
+	assert.Equal(t, nil, err)
+	n, err = buf.Write([]byte("defg"))
+	assert.Equal(t, 4, n)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "abcde", buf.String())
+}
+
+func TestAPIPost_Lenient_JSON_By_Default(t *testing.T) {
+	r := NewRouter()
+
+	var inputReq orderRequest
+	APIPost(r, loginPath, func(ctx Context, req orderRequest) (userGetResponse, error) {
+		inputReq = req
+		return userGetResponse{}, nil
+	})
+
+	writer := serveJSON(r, "", `{"note":"n1","other":1,"extra":1} trailing`)
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, orderRequest{UserID: 123, Note: "n1", Extra: 1.0}, inputReq)
+}
+
+func TestWithMaxBodySize(t *testing.T) {
+	r := NewRouter().WithOptions(WithMaxBodySize(32))
+
+	APIPost(r, loginPath, func(ctx Context, req orderRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+	APIPut(r, loginPath, func(ctx Context, req orderRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	}, WithMaxBodySize(1000))
+
+	body := `{"note":"` + strings.Repeat("a", 100) + `"}`
+
+	writer := serveJSON(r, "", body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, writer.Code)
+	assert.Equal(t,
+		`{"error":"request body exceeds the limit of 32 bytes","code":"request_too_large"}`+"\n",
+		writer.Body.String(),
+	)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/users/123", strings.NewReader(body))
+	putWriter := httptest.NewRecorder()
+	r.Mux().ServeHTTP(putWriter, req)
+	assert.Equal(t, http.StatusOK, putWriter.Code)
+}
+
+func TestWithRequiredContentType(t *testing.T) {
+	r := NewRouter().WithOptions(WithRequiredContentType("application/json"))
+
+	APIPost(r, loginPath, func(ctx Context, req orderRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+
+	t.Run("json", func(t *testing.T) {
+		writer := serveJSON(r, "application/json; charset=utf-8", `{"note":"n1"}`)
+		assert.Equal(t, http.StatusOK, writer.Code)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		writer := serveJSON(r, "", `{"note":"n1"}`)
+		assert.Equal(t, http.StatusUnsupportedMediaType, writer.Code)
+		assert.Equal(t,
+			`{"error":"missing content type","code":"unsupported_media_type","details":{"accepted":["application/json"]}}`+"\n",
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		writer := serveJSON(r, "text/plain", `{"note":"n1"}`)
+		assert.Equal(t, http.StatusUnsupportedMediaType, writer.Code)
+		assert.Equal(t,
+			`{"error":"unsupported content type 'text/plain'","code":"unsupported_media_type","details":{"accepted":["application/json"]}}`+"\n",
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("forms of html routes", func(t *testing.T) {
+		htmlRouter := NewRouter().WithOptions(WithRequiredContentType("application/json"))
+		HTMLPost(htmlRouter, userPath, func(ctx Context, req userFormRequest) (template.HTML, error) {
+			return "<div>Saved</div>", nil
+		})
+
+		form := url.Values{"body": {"Some Body"}}
+		writer := serveJSON(htmlRouter, "application/x-www-form-urlencoded", form.Encode())
+		assert.Equal(t, http.StatusOK, writer.Code)
+	})
+}
+
+func TestJSONFieldPath(t *testing.T) {
+	typ := reflect.TypeFor[orderRequest]()
+
+	field, path := jsonFieldPath(typ, []string{"items", "2", "count"})
+	assert.Equal(t, "Items[2].Count", field)
+	assert.Equal(t, "items[2].count", path)
+
+	field, path = jsonFieldPath(typ, []string{"NOTE"})
+	assert.Equal(t, "Note", field)
+	assert.Equal(t, "NOTE", path)
+
+	field, path = jsonFieldPath(typ, nil)
+	assert.Equal(t, "", field)
+	assert.Equal(t, "", path)
+}
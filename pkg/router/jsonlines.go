@@ -0,0 +1,71 @@
+package router
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// jsonLinesWriter writes values as json lines, it is the sink of JSONLinesReporter and FileExporter
+type jsonLinesWriter struct {
+	mut    sync.Mutex
+	path   string
+	writer io.Writer
+	closer io.Closer
+}
+
+// openJSONLines appends to the file at the path, the file is created if needed
+func openJSONLines(path string) (*jsonLinesWriter, error) {
+	file, err := openAppend(path)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonLinesWriter{writer: file, closer: file}, nil
+}
+
+func openAppend(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+}
+
+// lazyJSONLines opens the file at the path on the first write,
+// the values are written to stderr if the file can not be opened
+func lazyJSONLines(path string) *jsonLinesWriter {
+	return &jsonLinesWriter{path: path}
+}
+
+func (w *jsonLinesWriter) write(value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if w.writer == nil {
+		w.open()
+	}
+	_, _ = w.writer.Write(data)
+}
+
+// open must be called with mut locked
+func (w *jsonLinesWriter) open() {
+	file, err := openAppend(w.path)
+	if err != nil {
+		w.writer = os.Stderr
+		return
+	}
+	w.writer = file
+	w.closer = file
+}
+
+// Close closes the file
+func (w *jsonLinesWriter) Close() error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if w.closer == nil {
+		return nil
+	}
+	return w.closer.Close()
+}
@@ -0,0 +1,54 @@
+package router
+
+import (
+	"github.com/go-chi/chi/v5"
+	"learn-gin/pkg/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics returns a middleware recording the request count, latency, in-flight requests and response sizes
+// of the routes, labelled by method, route pattern and status. The registry can be served with its Handler.
+func Metrics(registry *metrics.Registry) HTTPMiddleware {
+	requests := registry.NewCounterVec(
+		"http_requests_total", "Total number of http requests.",
+		"method", "route", "status",
+	)
+	durations := registry.NewHistogramVec(
+		"http_request_duration_seconds", "Latency of the http requests in seconds.", nil,
+		"method", "route", "status",
+	)
+	inFlight := registry.NewGaugeVec(
+		"http_requests_in_flight", "Number of http requests being served.",
+		"method", "route",
+	)
+	sizes := registry.NewHistogramVec(
+		"http_response_size_bytes", "Size of the http response bodies in bytes.",
+		metrics.ExponentialBuckets(100, 10, 7),
+		"method", "route", "status",
+	)
+
+	return func(info RouteInfo, next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			route := info.Pattern
+			if routeCtx := chi.RouteContext(request.Context()); routeCtx != nil && len(routeCtx.RoutePatterns) > 0 {
+				route = routeCtx.RoutePattern()
+			}
+
+			gauge := inFlight.WithLabelValues(request.Method, route)
+			gauge.Inc()
+			defer gauge.Dec()
+
+			start := time.Now()
+			recorder := newResponseRecorder(writer)
+
+			next.ServeHTTP(recorder, request)
+
+			status := strconv.Itoa(recorder.Status())
+			requests.WithLabelValues(request.Method, route, status).Inc()
+			durations.WithLabelValues(request.Method, route, status).Observe(time.Since(start).Seconds())
+			sizes.WithLabelValues(request.Method, route, status).Observe(float64(recorder.bytes))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package router
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"learn-gin/pkg/metrics"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	r := NewRouter().WithHTTPMiddlewares(Metrics(registry))
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		if req.UserID == 404 {
+			return userGetResponse{}, NotFound("user not found")
+		}
+		return userGetResponse{UserID: req.UserID}, nil
+	})
+	r.Mux().Handle("/metrics", registry.Handler())
+
+	for _, url := range []string{"/api/users/1", "/api/users/2", "/api/users/404"} {
+		r.Mux().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+	}
+
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := writer.Body.String()
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/api/users/{user_id}",status="200"} 2`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/api/users/{user_id}",status="404"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/api/users/{user_id}",status="200"} 2`)
+	assert.Contains(t, body, `http_requests_in_flight{method="GET",route="/api/users/{user_id}"} 0`)
+	assert.Contains(t, body, `http_response_size_bytes_bucket{method="GET",route="/api/users/{user_id}",status="200",le="100"} 2`)
+	assert.False(t, strings.Contains(body, "/api/users/1\""))
+}
+
+func TestMetrics_In_Flight(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	r := NewRouter().WithHTTPMiddlewares(Metrics(registry))
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		var buf bytes.Buffer
+		_ = registry.WriteText(&buf)
+		assert.Contains(t, buf.String(), `http_requests_in_flight{method="GET",route="/api/users/{user_id}"} 1`)
+		return userGetResponse{}, nil
+	})
+
+	r.Mux().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/1", nil))
+}
@@ -0,0 +1,82 @@
+package router
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+func parseAccept(header string) []acceptRange {
+	var result []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+		result = append(result, acceptRange{mediaType: mediaType, quality: quality})
+	}
+	return result
+}
+
+// acceptQuality returns the quality of the most specific range matching the media type, -1 if none matches
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+
+	quality, specificity := -1.0, -1
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.mediaType == mediaType:
+			s = 2
+		case r.mediaType == mainType+"/*":
+			s = 1
+		case r.mediaType == "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			quality, specificity = r.quality, s
+		}
+	}
+	return quality
+}
+
+// negotiateMediaType returns the offer preferred by the Accept header of the request,
+// the first offer when the header is missing, or an empty string when no offer is acceptable
+func negotiateMediaType(request *http.Request, offers ...string) string {
+	header := request.Header.Get("Accept")
+	if len(header) == 0 {
+		return offers[0]
+	}
+
+	ranges := parseAccept(header)
+
+	best, bestQuality := "", 0.0
+	for _, offer := range offers {
+		quality := acceptQuality(ranges, offer)
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+	return best
+}
+
+// prefersJSON returns true if the client accepts json and prefers it over html
+func prefersJSON(request *http.Request) bool {
+	return negotiateMediaType(request, "text/html", "application/json") == "application/json"
+}
@@ -0,0 +1,466 @@
+package router
+
+import (
+	"encoding"
+	"encoding/json"
+	"learn-gin/pkg/null"
+	"learn-gin/pkg/openapi"
+	"learn-gin/pkg/urls"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OpenAPI generates the OpenAPI 3.1 document of all the registered routes
+func (r *Router) OpenAPI(info openapi.Info) *openapi.Document {
+	b := &openAPIBuilder{
+		doc: &openapi.Document{
+			OpenAPI: openapi.Version,
+			Info:    info,
+			Paths:   map[string]*openapi.PathItem{},
+			Components: openapi.Components{
+				Schemas: map[string]*openapi.Schema{},
+			},
+		},
+		names: map[reflect.Type]string{},
+	}
+
+	b.componentName(reflect.TypeFor[ErrorBody]())
+
+	for _, route := range r.Routes() {
+		if route.Kind != RouteKindDocs {
+			b.addRoute(route)
+		}
+	}
+	return b.doc
+}
+
+// ServeOpenAPI registers the handlers serving the document as {basePath}/openapi.json,
+// {basePath}/openapi.yaml and the docs page at {basePath}/docs.
+// The document is generated at the first request, after all routes have been registered.
+func (r *Router) ServeOpenAPI(basePath string, info openapi.Info) {
+	basePath = strings.TrimSuffix(r.prefix.pattern+basePath, "/")
+
+	var once sync.Once
+	var doc *openapi.Document
+	getDoc := func() *openapi.Document {
+		once.Do(func() {
+			doc = r.OpenAPI(info)
+		})
+		return doc
+	}
+
+	docsRoute := func(pattern string, handler http.HandlerFunc) {
+		r.register(RouteInfo{Kind: RouteKindDocs, Method: http.MethodGet, Pattern: basePath + pattern}, handler)
+	}
+	docsRoute("/openapi.json", openapi.JSONHandler(getDoc))
+	docsRoute("/openapi.yaml", openapi.YAMLHandler(getDoc))
+	docsRoute("/docs", openapi.UIHandler(getDoc, basePath+"/openapi.json"))
+}
+
+type openAPIBuilder struct {
+	doc   *openapi.Document
+	names map[reflect.Type]string
+}
+
+var pathParamRegex = regexp.MustCompile(`\{([^}:]+)(:[^}]+)?}`)
+
+func (b *openAPIBuilder) addRoute(route RouteInfo) {
+	path := pathParamRegex.ReplaceAllString(route.Pattern, "{$1}")
+
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = &openapi.PathItem{}
+		b.doc.Paths[path] = item
+	}
+
+	op := &openapi.Operation{
+		OperationID: operationID(route.Method, path),
+		Responses:   map[string]*openapi.Response{},
+	}
+	(*item)[strings.ToLower(route.Method)] = op
+
+	fields := requestFields(route.Request)
+
+	for _, p := range route.PathParams {
+		op.Parameters = append(op.Parameters, openapi.Parameter{
+			Name:     p,
+			In:       "path",
+			Required: true,
+			Schema:   b.paramSchema(fields, p, pathParamPattern(route.Pattern, p)),
+		})
+	}
+
+	for _, p := range route.QueryParams() {
+		param := openapi.Parameter{
+			Name:   p,
+			In:     "query",
+			Schema: b.paramSchema(fields, p, ""),
+		}
+		if f, ok := fields[p]; ok {
+			param.Required = hasRequiredRule(f.Tag.Get("validate"))
+			if f.Type.Kind() == reflect.Slice && urls.IsCommaSeparated(f) {
+				explode := false
+				param.Explode = &explode
+			}
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+
+	for _, f := range headerFields(route.Request) {
+		in, name := headerParamOf(f)
+
+		schema := b.schemaOf(f.Type)
+		applyValidateTag(schema, f.Type, f.Tag.Get("validate"))
+		op.Parameters = append(op.Parameters, openapi.Parameter{
+			Name:     name,
+			In:       in,
+			Required: hasRequiredRule(f.Tag.Get("validate")),
+			Schema:   schema,
+		})
+	}
+
+	if route.HasBody() && route.Request.Kind() == reflect.Struct {
+		bodySchema := b.structSchema(route.Request, route.AllParams)
+		if len(bodySchema.Properties) > 0 {
+			op.RequestBody = &openapi.RequestBody{
+				Required: bodyRuleOf(route.Method) == bodyRequired,
+				Content: map[string]openapi.MediaType{
+					"application/json": {Schema: bodySchema},
+				},
+			}
+			if route.FormBody {
+				if !hasFileField(route.Request) {
+					op.RequestBody.Content["application/x-www-form-urlencoded"] = openapi.MediaType{Schema: bodySchema}
+				}
+				op.RequestBody.Content["multipart/form-data"] = openapi.MediaType{Schema: bodySchema}
+			}
+		}
+	}
+
+	switch {
+	case isResponderType(route.Response):
+		op.Responses["200"] = &openapi.Response{Description: "OK"}
+	case route.Kind == RouteKindPage:
+		op.Responses["200"] = &openapi.Response{
+			Description: "OK",
+			Content: map[string]openapi.MediaType{
+				"text/html":        {Schema: &openapi.Schema{Type: "string"}},
+				"application/json": {Schema: b.schemaOf(route.Response)},
+			},
+		}
+	case route.Kind == RouteKindSSE:
+		op.Responses["200"] = &openapi.Response{
+			Description: "OK",
+			Content: map[string]openapi.MediaType{
+				"text/event-stream": {Schema: b.schemaOf(route.Response)},
+			},
+		}
+	case route.Kind == RouteKindHTML:
+		op.Responses["200"] = &openapi.Response{
+			Description: "OK",
+			Content: map[string]openapi.MediaType{
+				"text/html": {Schema: &openapi.Schema{Type: "string"}},
+			},
+		}
+	default:
+		op.Responses["200"] = &openapi.Response{
+			Description: "OK",
+			Content: map[string]openapi.MediaType{
+				"application/json": {Schema: b.schemaOf(route.Response)},
+			},
+		}
+	}
+
+	errContent := map[string]openapi.MediaType{
+		"application/json": {Schema: &openapi.Schema{Ref: "#/components/schemas/ErrorBody"}},
+	}
+	if len(route.AllParams) > 0 || route.HasBody() {
+		op.Responses["400"] = &openapi.Response{Description: "Bad Request", Content: errContent}
+	}
+	if route.Request.Kind() == reflect.Struct && typeHasValidation(route.Request) {
+		op.Responses["422"] = &openapi.Response{Description: "Unprocessable Entity", Content: errContent}
+	}
+	op.Responses["default"] = &openapi.Response{Description: "Error", Content: errContent}
+}
+
+func hasFileField(typ reflect.Type) bool {
+	for i := 0; i < typ.NumField(); i++ {
+		if isFileType(typ.Field(i).Type) {
+			return true
+		}
+	}
+	return false
+}
+
+func operationID(method string, path string) string {
+	var buf strings.Builder
+	buf.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(c rune) bool {
+		return c == '/' || c == '{' || c == '}' || c == '-' || c == '.'
+	}) {
+		buf.WriteString("_")
+		buf.WriteString(part)
+	}
+	return buf.String()
+}
+
+func pathParamPattern(pattern string, param string) string {
+	for _, match := range pathParamRegex.FindAllStringSubmatch(pattern, -1) {
+		if match[1] == param && len(match[2]) > 0 {
+			return "^" + match[2][1:] + "$"
+		}
+	}
+	return ""
+}
+
+func requestFields(typ reflect.Type) map[string]reflect.StructField {
+	result := map[string]reflect.StructField{}
+	if typ.Kind() != reflect.Struct {
+		return result
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		result[computeJsonName(f.Tag.Get("json"))] = f
+	}
+	return result
+}
+
+func (b *openAPIBuilder) paramSchema(fields map[string]reflect.StructField, name string, pattern string) *openapi.Schema {
+	f, ok := fields[name]
+	if !ok {
+		return &openapi.Schema{Type: "string", Pattern: pattern}
+	}
+
+	schema := b.schemaOf(f.Type)
+	applyValidateTag(schema, f.Type, f.Tag.Get("validate"))
+	if len(pattern) > 0 {
+		schema.Pattern = pattern
+	}
+	return schema
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+func (b *openAPIBuilder) schemaOf(typ reflect.Type) *openapi.Schema {
+	_, dataVal, ok := null.IsNullType(reflect.Zero(typ))
+	if ok {
+		return nullableSchema(b.schemaOf(dataVal.Type()))
+	}
+
+	if typ.Kind() == reflect.Pointer {
+		return nullableSchema(b.schemaOf(typ.Elem()))
+	}
+
+	if typ == timeType {
+		return &openapi.Schema{Type: "string", Format: "date-time"}
+	}
+	if typ == fileType {
+		return &openapi.Schema{Type: "string", Format: "binary"}
+	}
+	if typ.Implements(jsonMarshalerType) {
+		return &openapi.Schema{}
+	}
+	if typ.Implements(textMarshalerType) {
+		return &openapi.Schema{Type: "string"}
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return &openapi.Schema{Type: "string"}
+	case reflect.Bool:
+		return &openapi.Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uintptr:
+		return &openapi.Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &openapi.Schema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &openapi.Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &openapi.Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &openapi.Schema{Type: "string", Format: "byte"}
+		}
+		return &openapi.Schema{Type: "array", Items: b.schemaOf(typ.Elem())}
+	case reflect.Map:
+		return &openapi.Schema{Type: "object", AdditionalProperties: b.schemaOf(typ.Elem())}
+	case reflect.Struct:
+		if len(typ.Name()) == 0 {
+			return b.structSchema(typ, nil)
+		}
+		return &openapi.Schema{Ref: "#/components/schemas/" + b.componentName(typ)}
+	default:
+		return &openapi.Schema{}
+	}
+}
+
+func nullableSchema(schema *openapi.Schema) *openapi.Schema {
+	switch t := schema.Type.(type) {
+	case string:
+		schema.Type = []string{t, "null"}
+		return schema
+	case nil:
+		if len(schema.Ref) > 0 {
+			return &openapi.Schema{
+				OneOf: []*openapi.Schema{schema, {Type: "null"}},
+			}
+		}
+	}
+	return schema
+}
+
+var componentNameRegex = regexp.MustCompile(`[^a-zA-Z0-9_.]+`)
+
+func (b *openAPIBuilder) componentName(typ reflect.Type) string {
+	name, ok := b.names[typ]
+	if ok {
+		return name
+	}
+
+	base := strings.Trim(componentNameRegex.ReplaceAllString(typ.Name(), "_"), "_")
+	name = base
+	for i := 2; ; i++ {
+		if _, existed := b.doc.Components.Schemas[name]; !existed {
+			break
+		}
+		name = base + "_" + strconv.Itoa(i)
+	}
+
+	b.names[typ] = name
+	// reserved before building the schema to support recursive types
+	b.doc.Components.Schemas[name] = &openapi.Schema{}
+	*b.doc.Components.Schemas[name] = *b.structSchema(typ, nil)
+	return name
+}
+
+// structSchema builds the object schema of a struct, excluding the fields with json names in excluded
+func (b *openAPIBuilder) structSchema(typ reflect.Type, excluded []string) *openapi.Schema {
+	schema := &openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{},
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		jsonName := computeJsonName(f.Tag.Get("json"))
+		if jsonName == "-" || inList(excluded, jsonName) || isHeaderField(f) {
+			continue
+		}
+
+		if f.Anonymous && len(jsonName) == 0 && f.Type.Kind() == reflect.Struct {
+			embedded := b.structSchema(f.Type, excluded)
+			for k, v := range embedded.Properties {
+				schema.Properties[k] = v
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		if len(jsonName) == 0 {
+			jsonName = f.Name
+		}
+
+		fieldSchema := b.schemaOf(f.Type)
+		tag := f.Tag.Get("validate")
+		applyValidateTag(fieldSchema, f.Type, tag)
+
+		schema.Properties[jsonName] = fieldSchema
+		if hasRequiredRule(tag) {
+			schema.Required = append(schema.Required, jsonName)
+		}
+	}
+
+	return schema
+}
+
+func hasRequiredRule(tag string) bool {
+	rules, _, _ := parseValidateTag(tag)
+	for _, rule := range rules {
+		if rule.name == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+func applyValidateTag(schema *openapi.Schema, typ reflect.Type, tag string) {
+	if len(tag) == 0 {
+		return
+	}
+
+	rules, elemRules, dive := parseValidateTag(tag)
+	applyRules(schema, unwrapOptionalType(typ), rules)
+
+	if dive && schema.Items != nil {
+		applyRules(schema.Items, unwrapOptionalType(unwrapOptionalType(typ).Elem()), elemRules)
+	}
+}
+
+func applyRules(schema *openapi.Schema, typ reflect.Type, rules []validationRule) {
+	if len(schema.Ref) > 0 || len(schema.OneOf) > 0 {
+		return
+	}
+
+	kind := typ.Kind()
+	for _, rule := range rules {
+		num := rule.number
+		intNum := int(rule.number)
+
+		switch rule.name {
+		case "min", "max", "len":
+			switch {
+			case kind == reflect.String:
+				setLimit(rule.name, &schema.MinLength, &schema.MaxLength, intNum)
+			case kind == reflect.Slice || kind == reflect.Array:
+				setLimit(rule.name, &schema.MinItems, &schema.MaxItems, intNum)
+			case isNumberKind(kind) && rule.name == "min":
+				schema.Minimum = &num
+			case isNumberKind(kind) && rule.name == "max":
+				schema.Maximum = &num
+			}
+
+		case "oneof":
+			for _, option := range rule.oneOf {
+				if isIntegerKind(kind) {
+					intVal, err := strconv.ParseInt(option, 10, 64)
+					if err == nil {
+						schema.Enum = append(schema.Enum, intVal)
+						continue
+					}
+				}
+				schema.Enum = append(schema.Enum, option)
+			}
+
+		case "regex":
+			schema.Pattern = rule.param
+
+		case "email":
+			schema.Format = "email"
+		}
+	}
+}
+
+func setLimit(ruleName string, minVal **int, maxVal **int, num int) {
+	switch ruleName {
+	case "min":
+		*minVal = &num
+	case "max":
+		*maxVal = &num
+	default:
+		*minVal = &num
+		*maxVal = &num
+	}
+}
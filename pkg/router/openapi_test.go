@@ -0,0 +1,275 @@
+package router
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"html/template"
+	"learn-gin/pkg/null"
+	"learn-gin/pkg/openapi"
+	"learn-gin/pkg/urls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type openAPIItem struct {
+	Name  string            `json:"name" validate:"required,max=20"`
+	Price float64           `json:"price" validate:"min=0"`
+	Note  null.Null[string] `json:"note"`
+}
+
+type openAPIRequest struct {
+	UserID userID        `json:"user_id"`
+	Search string        `json:"search"`
+	Age    int           `json:"age"`
+	Status string        `json:"status" validate:"oneof=new done"`
+	Items  []openAPIItem `json:"items" validate:"min=1,dive"`
+	Tags   []string      `json:"tags" validate:"dive,max=5"`
+}
+
+type openAPIResponse struct {
+	ID      int64                  `json:"id"`
+	Item    null.Null[openAPIItem] `json:"item"`
+	Comment *string                `json:"comment"`
+}
+
+func TestRouter_OpenAPI(t *testing.T) {
+	r := NewRouter()
+
+	APIPost(r, userPath, func(ctx Context, req openAPIRequest) (openAPIResponse, error) {
+		return openAPIResponse{}, nil
+	})
+	APIGet(r, urls.New[userParams]("/api/users/{user_id:[0-9]+}/items"),
+		func(ctx Context, req userGetRequest) ([]openAPIItem, error) {
+			return nil, nil
+		},
+	)
+	HTMLGet(r, urls.NewEmpty("/"), func(ctx Context, req urls.Empty) (template.HTML, error) {
+		return "", nil
+	})
+
+	doc := r.OpenAPI(openapi.Info{Title: "Test API", Version: "1.0"})
+
+	data, err := json.Marshal(doc)
+	assert.Equal(t, nil, err)
+
+	var m map[string]any
+	assert.Equal(t, nil, json.Unmarshal(data, &m))
+
+	assert.Equal(t, "3.1.0", m["openapi"])
+	assert.Equal(t, map[string]any{"title": "Test API", "version": "1.0"}, m["info"])
+
+	paths := m["paths"].(map[string]any)
+	assert.Equal(t, 3, len(paths))
+
+	post := paths["/api/users/{user_id}"].(map[string]any)["post"].(map[string]any)
+	assert.Equal(t, "post_api_users_user_id", post["operationId"])
+	assert.Equal(t, []any{
+		map[string]any{
+			"name": "user_id", "in": "path", "required": true,
+			"schema": map[string]any{"type": "integer", "format": "int64"},
+		},
+		map[string]any{
+			"name": "search", "in": "query",
+			"schema": map[string]any{"type": "string"},
+		},
+		map[string]any{
+			"name": "age", "in": "query",
+			"schema": map[string]any{"type": "integer", "format": "int64"},
+		},
+	}, post["parameters"])
+
+	assert.Equal(t, map[string]any{
+		"required": true,
+		"content": map[string]any{
+			"application/json": map[string]any{
+				"schema": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"status": map[string]any{"type": "string", "enum": []any{"new", "done"}},
+						"items": map[string]any{
+							"type":     "array",
+							"minItems": float64(1),
+							"items":    map[string]any{"$ref": "#/components/schemas/openAPIItem"},
+						},
+						"tags": map[string]any{
+							"type":  "array",
+							"items": map[string]any{"type": "string", "maxLength": float64(5)},
+						},
+					},
+				},
+			},
+		},
+	}, post["requestBody"])
+
+	responses := post["responses"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"description": "OK",
+		"content": map[string]any{
+			"application/json": map[string]any{
+				"schema": map[string]any{"$ref": "#/components/schemas/openAPIResponse"},
+			},
+		},
+	}, responses["200"])
+	assert.Contains(t, responses, "400")
+	assert.Contains(t, responses, "422")
+	assert.Contains(t, responses, "default")
+
+	items := paths["/api/users/{user_id}/items"].(map[string]any)["get"].(map[string]any)
+	userIDParam := items["parameters"].([]any)[0].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"type": "integer", "format": "int64", "pattern": "^[0-9]+$",
+	}, userIDParam["schema"])
+
+	home := paths["/"].(map[string]any)["get"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"description": "OK",
+		"content": map[string]any{
+			"text/html": map[string]any{"schema": map[string]any{"type": "string"}},
+		},
+	}, home["responses"].(map[string]any)["200"])
+
+	schemas := m["components"].(map[string]any)["schemas"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "maxLength": float64(20)},
+			"price": map[string]any{"type": "number", "format": "double", "minimum": float64(0)},
+			"note":  map[string]any{"type": []any{"string", "null"}},
+		},
+		"required": []any{"name"},
+	}, schemas["openAPIItem"])
+
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id": map[string]any{"type": "integer", "format": "int64"},
+			"item": map[string]any{"oneOf": []any{
+				map[string]any{"$ref": "#/components/schemas/openAPIItem"},
+				map[string]any{"type": "null"},
+			}},
+			"comment": map[string]any{"type": []any{"string", "null"}},
+		},
+	}, schemas["openAPIResponse"])
+
+	assert.Contains(t, schemas, "ErrorBody")
+	assert.Contains(t, schemas, "FieldError")
+}
+
+func TestRouter_OpenAPI_With_Group_And_Mount(t *testing.T) {
+	sub := NewRouter()
+	APIGet(sub, datasetRowPath, func(ctx Context, req datasetRowParams) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+
+	r := NewRouter()
+	ds := Group(r, datasetPrefix)
+	APIDelete(ds, datasetRowPath, func(ctx Context, req datasetRowRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+	Mount(r, urls.NewEmpty("/admin"), sub)
+
+	var patterns []string
+	for _, route := range r.Routes() {
+		patterns = append(patterns, route.Method+" "+route.Pattern)
+	}
+	assert.Equal(t, []string{
+		"GET /admin/rows/{row_id}",
+		"DELETE /api/datasets/{dataset_id}/rows/{row_id}",
+	}, patterns)
+
+	doc := r.OpenAPI(openapi.Info{Title: "Test", Version: "1"})
+	op := (*doc.Paths["/api/datasets/{dataset_id}/rows/{row_id}"])["delete"]
+	assert.Equal(t, 2, len(op.Parameters))
+	assert.Equal(t, "dataset_id", op.Parameters[0].Name)
+	assert.Equal(t, "row_id", op.Parameters[1].Name)
+}
+
+func TestRouter_ServeOpenAPI(t *testing.T) {
+	r := NewRouter()
+	r.ServeOpenAPI("/api", openapi.Info{Title: "Test API", Version: "1.0"})
+
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "application/json; charset=utf-8", writer.Header().Get("Content-Type"))
+	assert.Contains(t, writer.Body.String(), `"/api/users/{user_id}"`)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/openapi.yaml", nil)
+	writer = httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, true, strings.HasPrefix(writer.Body.String(), "openapi: 3.1.0\n"))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/docs", nil)
+	writer = httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "text/html; charset=utf-8", writer.Header().Get("Content-Type"))
+	assert.Contains(t, writer.Body.String(), `<a href="/api/openapi.json">/api/openapi.json</a>`)
+	assert.Contains(t, writer.Body.String(),
+		"<tr><td>GET</td><td><code>/api/users/{user_id}</code></td><td>get_api_users_user_id</td></tr>",
+	)
+	assert.NotContains(t, writer.Body.String(), "/api/docs")
+	assert.NotContains(t, writer.Body.String(), "<script")
+
+	req = httptest.NewRequest(http.MethodOptions, "/api/openapi.json", nil)
+	writer = httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusNoContent, writer.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS", writer.Header().Get("Allow"))
+}
+
+func TestRouter_OpenAPI_Header_And_Cookie_Params(t *testing.T) {
+	r := NewRouter()
+
+	APIPost(r, userPath, func(ctx Context, req userHeaderRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+
+	doc := r.OpenAPI(openapi.Info{Title: "Test", Version: "1"})
+	op := (*doc.Paths["/api/users/{user_id}"])["post"]
+
+	assert.Equal(t, openapi.Parameter{
+		Name: "X-Tenant-ID", In: "header", Schema: &openapi.Schema{Type: "string"},
+	}, op.Parameters[3])
+	assert.Equal(t, openapi.Parameter{
+		Name: "session", In: "cookie", Schema: &openapi.Schema{Type: "string"},
+	}, op.Parameters[4])
+
+	bodySchema := op.RequestBody.Content["application/json"].Schema
+	assert.Equal(t, map[string]*openapi.Schema{
+		"body": {Type: "string"},
+	}, bodySchema.Properties)
+}
+
+func TestRouter_OpenAPI_Comma_Separated_Query_Params(t *testing.T) {
+	r := NewRouter()
+
+	APIGet(r, urls.New[richParams]("/api/rich/{name}"), func(ctx Context, req richParams) (urls.Empty, error) {
+		return urls.Empty{}, nil
+	})
+
+	doc := r.OpenAPI(openapi.Info{Title: "Test", Version: "1"})
+	op := (*doc.Paths["/api/rich/{name}"])["get"]
+
+	explode := false
+	for _, param := range op.Parameters {
+		switch param.Name {
+		case "tags":
+			assert.Equal(t, &explode, param.Explode)
+		case "keys":
+			assert.Equal(t, (*bool)(nil), param.Explode)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package router
+
+import (
+	"time"
+)
+
+type routerConfig struct {
+	errorMapper          ErrorMapper
+	exposeInternalErrors bool
+	errorReporter        ErrorReporter
+	requestID            bool
+	tracer               Tracer
+	errorPages           map[string]ErrorTemplate
+	devErrorPages        bool
+	encoders             []pageEncoder
+	sseHeartbeat         time.Duration
+	cors                 *corsPolicy
+	csrf                 *csrfProtection
+
+	formBody           bool
+	multipartMaxMemory int64
+	multipartMaxSize   int64
+
+	decompressedMaxSize int64
+
+	maxBodySize           int64
+	disallowUnknownFields bool
+	rejectTrailingData    bool
+	useNumber             bool
+	contentTypes          []string
+}
+
+type Option func(conf *routerConfig)
+
+// WithOptions returns a new router sharing the same mux with the options applied
+func (r *Router) WithOptions(opts ...Option) *Router {
+	newR := *r
+	for _, opt := range opts {
+		opt(&newR.config)
+	}
+	return &newR
+}
+
+// WithErrorMapper sets the function that translates errors not of type *Error
+func WithErrorMapper(mapper ErrorMapper) Option {
+	return func(conf *routerConfig) {
+		conf.errorMapper = mapper
+	}
+}
+
+// WithExposedInternalErrors sends the messages of unrecognized errors having status >= 500 to clients,
+// they are replaced by a generic message by default. It is meant for development only
+func WithExposedInternalErrors() Option {
+	return func(conf *routerConfig) {
+		conf.exposeInternalErrors = true
+	}
+}
+
+// WithFormBody enables the decoding of url encoded and multipart forms for api routes,
+// html routes always accept forms
+func WithFormBody() Option {
+	return func(conf *routerConfig) {
+		conf.formBody = true
+	}
+}
+
+// WithMultipartLimits sets the max bytes of a form kept in memory (the rest is stored in temporary files)
+// and the max size of the whole form body, zero means the default memory limit of 32MB and an unlimited size
+func WithMultipartLimits(maxMemory int64, maxSize int64) Option {
+	return func(conf *routerConfig) {
+		conf.multipartMaxMemory = maxMemory
+		conf.multipartMaxSize = maxSize
+	}
+}
+
+// WithDecompressedBodyLimit sets the max size of a gzip or deflate encoded request body
+// once decompressed, 10MB by default
+func WithDecompressedBodyLimit(maxSize int64) Option {
+	return func(conf *routerConfig) {
+		conf.decompressedMaxSize = maxSize
+	}
+}
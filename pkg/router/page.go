@@ -0,0 +1,234 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"learn-gin/pkg/urls"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Template renders a view model to html, it is implemented by *views.Template
+type Template interface {
+	Render(data any) (template.HTML, error)
+}
+
+// Encoder writes the view model of a page in a format other than html or json
+type Encoder func(w io.Writer, v any) error
+
+type pageEncoder struct {
+	format    string
+	mediaType string
+	encode    Encoder
+}
+
+const (
+	formatHTML = "html"
+	formatJSON = "json"
+)
+
+// WithEncoder registers an encoder used by the pages for the format, the format is selected by
+// a suffix of the url (e.g. /users/1.csv), the format query param or the media type in the Accept header
+func WithEncoder(format string, mediaType string, encode Encoder) Option {
+	return func(conf *routerConfig) {
+		conf.encoders = slices.DeleteFunc(slices.Clone(conf.encoders), func(e pageEncoder) bool {
+			return e.format == format
+		})
+		conf.encoders = append(conf.encoders, pageEncoder{
+			format:    format,
+			mediaType: mediaType,
+			encode:    encode,
+		})
+	}
+}
+
+// EncodeXML is an Encoder writing the view model as xml
+func EncodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// Page registers a GET route whose view model is rendered with the template for html clients,
+// encoded as json or encoded by the encoders registered with WithEncoder
+func Page[T any, Req any, Resp any](
+	r *Router, pattern urls.Path[T],
+	tmpl Template,
+	handler func(ctx Context, req Req) (Resp, error),
+	opts ...Option,
+) {
+	r = r.WithOptions(opts...)
+
+	var testPathVal T
+	var testReqVal Req
+	urls.CheckIsSubStruct(testReqVal, testPathVal)
+	r.checkRequestType(testReqVal)
+	checkValidation(testReqVal)
+
+	path := newRoutePath(r, pattern)
+	plan := newBindingPlan(reflect.TypeFor[Req](), path)
+
+	genericHandler := func(ctx Context, req any) (resp any, err error) {
+		return handler(ctx, req.(Req))
+	}
+	genericHandler = r.wrapHandler(genericHandler)
+
+	serve := func(writer http.ResponseWriter, request *http.Request, suffixFormat string) {
+		request, err := r.issueCSRF(writer, request)
+		ctx := NewContext(writer, request)
+		writer.Header().Add("Vary", "Accept")
+		if err != nil {
+			r.writeHTMLResp(ctx, nil, err, http.StatusInternalServerError)
+			return
+		}
+
+		format, err := r.pageFormat(request, suffixFormat)
+		if err != nil {
+			r.writeAPIResp(ctx, nil, err, http.StatusNotAcceptable)
+			return
+		}
+
+		var req Req
+		if err := plan.bind(request, &req); err != nil {
+			r.writePageResp(ctx, format, nil, err, http.StatusBadRequest)
+			return
+		}
+
+		ctx.state.req = req
+
+		if err := validateRequest(&req); err != nil {
+			r.writePageResp(ctx, format, nil, err, http.StatusUnprocessableEntity)
+			return
+		}
+
+		respBody, err := genericHandler(ctx, req)
+		if err == nil && format == formatHTML {
+			if _, ok := responderOf(ctx, respBody); !ok {
+				respBody, err = renderTemplate(ctx, tmpl, respBody)
+			}
+		}
+		r.writePageResp(ctx, format, respBody, err, http.StatusInternalServerError)
+	}
+
+	info := newRouteInfo[Req, Resp](RouteKindPage, http.MethodGet, path, false)
+	r.register(info, func(writer http.ResponseWriter, request *http.Request) {
+		serve(writer, request, "")
+	})
+
+	if strings.HasSuffix(path.pattern, "/") {
+		return
+	}
+	for _, format := range r.pageFormats() {
+		suffixInfo := info
+		suffixInfo.Pattern = path.pattern + "." + format
+		r.register(suffixInfo, func(writer http.ResponseWriter, request *http.Request) {
+			serve(writer, request, format)
+		})
+	}
+}
+
+// contextTemplate is implemented by the templates rendering the values of the request, e.g. *views.Template,
+// the context is the Context of the request
+type contextTemplate interface {
+	RenderContext(ctx context.Context, data any) (template.HTML, error)
+}
+
+func renderTemplate(ctx Context, tmpl Template, data any) (template.HTML, error) {
+	if t, ok := tmpl.(contextTemplate); ok {
+		return t.RenderContext(ctx, data)
+	}
+	return tmpl.Render(data)
+}
+
+func (r *Router) pageFormats() []string {
+	formats := []string{formatHTML, formatJSON}
+	for _, e := range r.config.encoders {
+		if !slices.Contains(formats, e.format) {
+			formats = append(formats, e.format)
+		}
+	}
+	return formats
+}
+
+func (r *Router) pageEncoder(format string) (pageEncoder, bool) {
+	for _, e := range r.config.encoders {
+		if e.format == format {
+			return e, true
+		}
+	}
+	return pageEncoder{}, false
+}
+
+// pageFormat selects the format from the url suffix, then the format query param, then the Accept header
+func (r *Router) pageFormat(request *http.Request, suffixFormat string) (string, error) {
+	if len(suffixFormat) > 0 {
+		return suffixFormat, nil
+	}
+
+	formats := r.pageFormats()
+
+	if format := request.URL.Query().Get("format"); len(format) > 0 {
+		if !slices.Contains(formats, format) {
+			return "", NewError(http.StatusNotAcceptable, "not_acceptable", fmt.Sprintf("unsupported format '%s'", format))
+		}
+		return format, nil
+	}
+
+	offers := []string{"text/html", "application/json"}
+	offerFormats := []string{formatHTML, formatJSON}
+	for _, e := range r.config.encoders {
+		if e.format == formatHTML || e.format == formatJSON {
+			continue
+		}
+		offers = append(offers, e.mediaType)
+		offerFormats = append(offerFormats, e.format)
+	}
+
+	mediaType := negotiateMediaType(request, offers...)
+	index := slices.Index(offers, mediaType)
+	if index < 0 {
+		return "", NewError(http.StatusNotAcceptable, "not_acceptable", "none of the accepted media types is supported")
+	}
+	return offerFormats[index], nil
+}
+
+func (r *Router) writePageResp(ctx Context, format string, respBody any, err error, status int) {
+	switch format {
+	case formatHTML:
+		r.writeHTMLResp(ctx, respBody, err, status)
+		return
+	case formatJSON:
+		r.writeAPIResp(ctx, respBody, err, status)
+		return
+	}
+
+	if err != nil {
+		r.writeAPIResp(ctx, nil, err, status)
+		return
+	}
+	if responder, ok := responderOf(ctx, respBody); ok {
+		if err := responder.Respond(ctx); err != nil {
+			r.writeAPIResp(ctx, nil, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	encoder, _ := r.pageEncoder(format)
+
+	var buf bytes.Buffer
+	if err := encoder.encode(&buf, respBody); err != nil {
+		r.writeAPIResp(ctx, nil, err, http.StatusInternalServerError)
+		return
+	}
+
+	ctx.writer.Header().Set("Content-Type", encoder.mediaType)
+	ctx.writer.WriteHeader(ctx.successStatus())
+	_, _ = ctx.writer.Write(buf.Bytes())
+}
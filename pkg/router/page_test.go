@@ -0,0 +1,141 @@
+package router
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type userView struct {
+	UserID   userID `json:"user_id" xml:"id"`
+	Username string `json:"username" xml:"name"`
+}
+
+func encodeUserCSV(w io.Writer, v any) error {
+	user := v.(userView)
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"user_id", "username"})
+	_ = writer.Write([]string{fmt.Sprint(user.UserID), user.Username})
+	writer.Flush()
+	return writer.Error()
+}
+
+func newPageRouter() *Router {
+	r := NewRouter().WithOptions(
+		WithEncoder("csv", "text/csv", encodeUserCSV),
+		WithEncoder("xml", "application/xml", EncodeXML),
+	)
+
+	tmpl := loadTestTemplate(`<h1>{{.Username}}</h1>`)
+	Page(r, userPath, tmpl, func(ctx Context, req userGetRequest) (userView, error) {
+		if req.UserID == 404 {
+			return userView{}, NotFound("user not found")
+		}
+		return userView{UserID: req.UserID, Username: "user01"}, nil
+	})
+	return r
+}
+
+func TestPage(t *testing.T) {
+	r := newPageRouter()
+
+	serve := func(url string, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if len(accept) > 0 {
+			req.Header.Set("Accept", accept)
+		}
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+		return writer
+	}
+
+	t.Run("html by default", func(t *testing.T) {
+		writer := serve("/api/users/123", "")
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, "<h1>user01</h1>", writer.Body.String())
+		assert.Equal(t, http.Header{
+			"Content-Type": []string{"text/html; charset=utf-8"},
+			"Vary":         []string{"Accept"},
+		}, writer.Header())
+	})
+
+	t.Run("json by accept", func(t *testing.T) {
+		writer := serve("/api/users/123", "application/json")
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, `{"user_id":123,"username":"user01"}`+"\n", writer.Body.String())
+		assert.Equal(t, "application/json; charset=utf-8", writer.Header().Get("Content-Type"))
+	})
+
+	t.Run("json by suffix", func(t *testing.T) {
+		writer := serve("/api/users/123.json", "text/html")
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, `{"user_id":123,"username":"user01"}`+"\n", writer.Body.String())
+	})
+
+	t.Run("csv by query param", func(t *testing.T) {
+		writer := serve("/api/users/123?format=csv", "")
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, "user_id,username\n123,user01\n", writer.Body.String())
+		assert.Equal(t, "text/csv", writer.Header().Get("Content-Type"))
+	})
+
+	t.Run("xml by accept", func(t *testing.T) {
+		writer := serve("/api/users/123", "application/xml")
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t,
+			`<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<userView><id>123</id><name>user01</name></userView>`,
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		writer := serve("/api/users/123?format=pdf", "")
+		assert.Equal(t, http.StatusNotAcceptable, writer.Code)
+		assert.Equal(t, `{"error":"unsupported format 'pdf'","code":"not_acceptable"}`+"\n", writer.Body.String())
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		writer := serve("/api/users/123", "image/png")
+		assert.Equal(t, http.StatusNotAcceptable, writer.Code)
+	})
+
+	t.Run("error as json", func(t *testing.T) {
+		writer := serve("/api/users/404.json", "")
+		assert.Equal(t, http.StatusNotFound, writer.Code)
+		assert.Equal(t, `{"error":"user not found","code":"not_found"}`+"\n", writer.Body.String())
+	})
+
+	t.Run("error as html page", func(t *testing.T) {
+		writer := serve("/api/users/404", "text/html")
+		assert.Equal(t, http.StatusNotFound, writer.Code)
+		assert.Contains(t, writer.Body.String(), "<h1>404 Not Found</h1>")
+	})
+}
+
+func TestPage_Routes(t *testing.T) {
+	r := newPageRouter()
+
+	var patterns []string
+	for _, info := range r.Routes() {
+		assert.Equal(t, RouteKindPage, info.Kind)
+		patterns = append(patterns, info.Pattern)
+	}
+	assert.Equal(t, []string{
+		"/api/users/{user_id}",
+		"/api/users/{user_id}.csv",
+		"/api/users/{user_id}.html",
+		"/api/users/{user_id}.json",
+		"/api/users/{user_id}.xml",
+	}, patterns)
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/users/123.json", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusNoContent, writer.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS", writer.Header().Get("Allow"))
+}
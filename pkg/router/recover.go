@@ -0,0 +1,156 @@
+package router
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// PanicError is the cause of the internal error returned when a handler or a middleware panics
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// ErrorReport describes a panic recovered by the router
+type ErrorReport struct {
+	Time      time.Time `json:"time"`
+	Error     string    `json:"error"`
+	Stack     string    `json:"stack"`
+	Method    string    `json:"method"`
+	Route     string    `json:"route"`
+	URL       string    `json:"url"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// ErrorReporter receives the panics recovered by the router, it must be safe for concurrent use
+type ErrorReporter interface {
+	Report(report ErrorReport)
+}
+
+// WithErrorReporter sets the reporter of the recovered panics, see DefaultErrorReportPath for the default reporter
+func WithErrorReporter(reporter ErrorReporter) Option {
+	return func(conf *routerConfig) {
+		conf.errorReporter = reporter
+	}
+}
+
+// JSONLinesReporter writes the reports as json lines
+type JSONLinesReporter struct {
+	*jsonLinesWriter
+}
+
+// DefaultErrorReportPath is the file the reports are appended to when no reporter is set,
+// it is relative to the working directory and only created on the first report
+const DefaultErrorReportPath = "error_reports.jsonl"
+
+var defaultErrorReporter = &JSONLinesReporter{lazyJSONLines(DefaultErrorReportPath)}
+
+// NewFileReporter returns a reporter appending to the file at the path, the file is created if needed
+func NewFileReporter(path string) (*JSONLinesReporter, error) {
+	w, err := openJSONLines(path)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLinesReporter{w}, nil
+}
+
+// NewWriterReporter returns a reporter writing to the writer
+func NewWriterReporter(writer io.Writer) *JSONLinesReporter {
+	return &JSONLinesReporter{&jsonLinesWriter{writer: writer}}
+}
+
+func (r *JSONLinesReporter) Report(report ErrorReport) {
+	r.write(report)
+}
+
+func (r *Router) errorReporter() ErrorReporter {
+	if r.config.errorReporter == nil {
+		return defaultErrorReporter
+	}
+	return r.config.errorReporter
+}
+
+// recoverHandler converts the panics of the handler into internal errors and reports them.
+// http.ErrAbortHandler is not recovered, since it is used to abort the response on purpose.
+func (r *Router) recoverHandler(handler GenericHandler) GenericHandler {
+	reporter := r.errorReporter()
+
+	return func(ctx Context, req any) (resp any, err error) {
+		defer func() {
+			value := recover()
+			if value == nil {
+				return
+			}
+			if value == http.ErrAbortHandler {
+				panic(value)
+			}
+
+			panicErr := &PanicError{Value: value, Stack: debug.Stack()}
+			ctx.state.stack = panicErr.Stack
+			reporter.Report(newErrorReport(ctx, panicErr))
+
+			resp, err = nil, Internal(panicErr)
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// recoverHTTPHandler recovers the panics escaping the handler of the route, e.g. in the http middlewares,
+// the responders or the rendering of the templates. An internal error is written if the response is not started,
+// otherwise the response is aborted.
+func (r *Router) recoverHTTPHandler(info RouteInfo, handler http.HandlerFunc) http.HandlerFunc {
+	reporter := r.errorReporter()
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		recorder := newResponseRecorder(writer)
+		defer func() {
+			value := recover()
+			if value == nil {
+				return
+			}
+			if value == http.ErrAbortHandler {
+				panic(value)
+			}
+
+			panicErr := &PanicError{Value: value, Stack: debug.Stack()}
+			ctx := NewContext(writer, request)
+			ctx.state.stack = panicErr.Stack
+			reporter.Report(newErrorReport(ctx, panicErr))
+
+			if recorder.status != 0 {
+				panic(http.ErrAbortHandler)
+			}
+			if info.Kind == RouteKindHTML || info.Kind == RouteKindPage {
+				r.writeHTMLResp(ctx, nil, Internal(panicErr), http.StatusInternalServerError)
+			} else {
+				r.writeAPIResp(ctx, nil, Internal(panicErr), http.StatusInternalServerError)
+			}
+		}()
+		handler(recorder, request)
+	}
+}
+
+func newErrorReport(ctx Context, err *PanicError) ErrorReport {
+	request := ctx.request
+
+	report := ErrorReport{
+		Time:      time.Now(),
+		Error:     err.Error(),
+		Stack:     string(err.Stack),
+		Method:    request.Method,
+		URL:       request.URL.String(),
+		RequestID: ctx.RequestID(),
+	}
+	if routeCtx := chi.RouteContext(request.Context()); routeCtx != nil {
+		report.Route = routeCtx.RoutePattern()
+	}
+	return report
+}
@@ -0,0 +1,275 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"html/template"
+	"io"
+	"learn-gin/pkg/metrics"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memoryReporter struct {
+	mut     sync.Mutex
+	reports []ErrorReport
+}
+
+func (r *memoryReporter) Report(report ErrorReport) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.reports = append(r.reports, report)
+}
+
+func TestAPIGet_Panic(t *testing.T) {
+	reporter := &memoryReporter{}
+	r := NewRouter().WithOptions(WithErrorReporter(reporter), WithRequestID())
+
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		panic("something broken")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	req.Header.Set("X-Request-ID", "req01")
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusInternalServerError, writer.Code)
+	assert.Equal(t,
+		`{"error":"internal server error","code":"internal_error","request_id":"req01"}`+"\n",
+		writer.Body.String(),
+	)
+
+	assert.Equal(t, 1, len(reporter.reports))
+	report := reporter.reports[0]
+	assert.Equal(t, "panic: something broken", report.Error)
+	assert.Equal(t, http.MethodGet, report.Method)
+	assert.Equal(t, "/api/users/{user_id}", report.Route)
+	assert.Equal(t, "/api/users/123", report.URL)
+	assert.Equal(t, "req01", report.RequestID)
+	assert.True(t, strings.Contains(report.Stack, "recover_test.go"))
+}
+
+func TestHTMLGet_Panic_In_Middleware(t *testing.T) {
+	reporter := &memoryReporter{}
+	r := NewRouter().WithOptions(WithErrorReporter(reporter)).WithMiddlewares(
+		func(handler GenericHandler) GenericHandler {
+			return func(ctx Context, req any) (any, error) {
+				var m map[string]int
+				m["x"] = 1
+				return handler(ctx, req)
+			}
+		},
+	)
+
+	HTMLGet(r, userPath, func(ctx Context, req userGetRequest) (template.HTML, error) {
+		return "<div>Hello</div>", nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusInternalServerError, writer.Code)
+	assert.Contains(t, writer.Body.String(), "<h1>500 Internal Server Error</h1>")
+	assert.Equal(t, "text/html; charset=utf-8", writer.Header().Get("Content-Type"))
+	assert.Equal(t, "panic: assignment to entry in nil map", reporter.reports[0].Error)
+}
+
+func TestHTMLGet_Panic_Dev_Error_Page_Shows_Stack(t *testing.T) {
+	r := NewRouter().WithOptions(WithErrorReporter(&memoryReporter{}), WithDevErrorPages())
+
+	HTMLGet(r, userPath, func(ctx Context, req userGetRequest) (template.HTML, error) {
+		panic("something broken")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusInternalServerError, writer.Code)
+	assert.Contains(t, writer.Body.String(), "*router.PanicError: panic: something broken")
+	assert.Contains(t, writer.Body.String(), "<h2>Stack</h2>")
+}
+
+func TestAPIGet_Abort_Handler_Not_Recovered(t *testing.T) {
+	r := NewRouter().WithOptions(WithErrorReporter(&memoryReporter{}))
+
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		panic(http.ErrAbortHandler)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		r.Mux().ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
+
+func TestDefaultErrorReporter_Opens_File_On_First_Report(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultErrorReportPath)
+	reporter := &JSONLinesReporter{lazyJSONLines(path)}
+
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	reporter.Report(ErrorReport{Error: "panic: first", Route: "/a"})
+	assert.Equal(t, nil, reporter.Close())
+
+	data, err := os.ReadFile(path)
+	assert.Equal(t, nil, err)
+	assert.Contains(t, string(data), `"error":"panic: first"`)
+}
+
+func TestFileReporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.log")
+
+	reporter, err := NewFileReporter(path)
+	assert.Equal(t, nil, err)
+
+	reporter.Report(ErrorReport{Error: "panic: first", Route: "/a"})
+	reporter.Report(ErrorReport{Error: "panic: second", Route: "/b", RequestID: "req01"})
+	assert.Equal(t, nil, reporter.Close())
+
+	data, err := os.ReadFile(path)
+	assert.Equal(t, nil, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(t, 2, len(lines))
+
+	var report ErrorReport
+	assert.Equal(t, nil, json.Unmarshal([]byte(lines[1]), &report))
+	assert.Equal(t, "panic: second", report.Error)
+	assert.Equal(t, "/b", report.Route)
+	assert.Equal(t, "req01", report.RequestID)
+}
+
+type panicResponder struct{}
+
+func (panicResponder) Respond(ctx Context) error {
+	panic("responder broken")
+}
+
+type panicTemplate struct{}
+
+func (panicTemplate) Render(data any) (template.HTML, error) {
+	panic("template broken")
+}
+
+func TestPanic_Outside_Handler(t *testing.T) {
+	serve := func(r *Router, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+		return writer
+	}
+
+	t.Run("responder", func(t *testing.T) {
+		reporter := &memoryReporter{}
+		r := NewRouter().WithOptions(WithErrorReporter(reporter), WithRequestID())
+
+		APIGet(r, userPath, func(ctx Context, req userGetRequest) (panicResponder, error) {
+			return panicResponder{}, nil
+		})
+
+		writer := serve(r, http.Header{"X-Request-Id": {"req01"}})
+		assert.Equal(t, http.StatusInternalServerError, writer.Code)
+		assert.Equal(t,
+			`{"error":"internal server error","code":"internal_error","request_id":"req01"}`+"\n",
+			writer.Body.String(),
+		)
+		assert.Equal(t, 1, len(reporter.reports))
+		assert.Equal(t, "panic: responder broken", reporter.reports[0].Error)
+		assert.Equal(t, "/api/users/{user_id}", reporter.reports[0].Route)
+		assert.Equal(t, "req01", reporter.reports[0].RequestID)
+	})
+
+	t.Run("http middleware", func(t *testing.T) {
+		reporter := &memoryReporter{}
+		r := NewRouter().WithOptions(WithErrorReporter(reporter)).WithHTTPMiddlewares(
+			func(info RouteInfo, next http.Handler) http.Handler {
+				return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+					panic("middleware broken")
+				})
+			},
+		)
+
+		HTMLGet(r, userPath, func(ctx Context, req userGetRequest) (template.HTML, error) {
+			return "<div>Hello</div>", nil
+		})
+
+		writer := serve(r, nil)
+		assert.Equal(t, http.StatusInternalServerError, writer.Code)
+		assert.Contains(t, writer.Body.String(), "<h1>500 Internal Server Error</h1>")
+		assert.Equal(t, "panic: middleware broken", reporter.reports[0].Error)
+	})
+
+	t.Run("page template", func(t *testing.T) {
+		reporter := &memoryReporter{}
+		r := NewRouter().WithOptions(WithErrorReporter(reporter))
+
+		Page(r, userPath, panicTemplate{}, func(ctx Context, req userGetRequest) (userView, error) {
+			return userView{}, nil
+		})
+
+		writer := serve(r, nil)
+		assert.Equal(t, http.StatusInternalServerError, writer.Code)
+		assert.Contains(t, writer.Body.String(), "<h1>500 Internal Server Error</h1>")
+		assert.Equal(t, "panic: template broken", reporter.reports[0].Error)
+	})
+
+	t.Run("response started", func(t *testing.T) {
+		reporter := &memoryReporter{}
+		r := NewRouter().WithOptions(WithErrorReporter(reporter))
+
+		APIGet(r, userPath, func(ctx Context, req userGetRequest) (StreamResponse, error) {
+			return Stream("text/plain", func(w io.Writer) error {
+				_, _ = w.Write([]byte("partial"))
+				panic("stream broken")
+			}), nil
+		})
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			serve(r, nil)
+		})
+		assert.Equal(t, "panic: stream broken", reporter.reports[0].Error)
+	})
+}
+
+func TestPanic_Observed_By_HTTP_Middlewares(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	registry := metrics.NewRegistry()
+
+	r := NewRouter().
+		WithOptions(WithErrorReporter(&memoryReporter{})).
+		WithHTTPMiddlewares(AccessLog(AccessLogOptions{Logger: logger}), Metrics(registry))
+
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (panicResponder, error) {
+		return panicResponder{}, nil
+	})
+
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/api/users/123", nil))
+	assert.Equal(t, http.StatusInternalServerError, writer.Code)
+
+	records := decodeLogLines(t, &buf)
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, float64(http.StatusInternalServerError), records[0]["status"])
+
+	var text bytes.Buffer
+	assert.Equal(t, nil, registry.WriteText(&text))
+	assert.Contains(t, text.String(), `http_requests_total{method="GET",route="/api/users/{user_id}",status="500"} 1`)
+	assert.Contains(t, text.String(),
+		`http_request_duration_seconds_count{method="GET",route="/api/users/{user_id}",status="500"} 1`,
+	)
+}
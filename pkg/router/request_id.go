@@ -0,0 +1,90 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// RequestIDHeader is the header used to receive, echo and forward the request id
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// WithRequestID makes the routes take the request id from the X-Request-ID header,
+// or the trace id of the traceparent header, or generate a new one.
+// The id is stored in the request context, echoed in the response and forwarded by the typed client.
+func WithRequestID() Option {
+	return func(conf *routerConfig) {
+		conf.requestID = true
+	}
+}
+
+// ContextWithRequestID returns a copy of the context carrying the request id
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id stored in the context, or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID returns the id of the request, see WithRequestID
+func (c Context) RequestID() string {
+	return requestIDOf(c.request)
+}
+
+// requestIDOf returns the id generated or validated by WithRequestID,
+// the X-Request-ID header of the client is never used without it
+func requestIDOf(request *http.Request) string {
+	return RequestIDFromContext(request.Context())
+}
+
+func requestIDHandler(handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id := incomingRequestID(request)
+		if len(id) == 0 {
+			id = newRequestID()
+		}
+
+		writer.Header().Set(RequestIDHeader, id)
+		handler(writer, request.WithContext(ContextWithRequestID(request.Context(), id)))
+	}
+}
+
+func incomingRequestID(request *http.Request) string {
+	if id := request.Header.Get(RequestIDHeader); isValidRequestID(id) {
+		return id
+	}
+	if sc, ok := ParseTraceparent(request.Header.Get("traceparent"), ""); ok {
+		return sc.TraceIDString()
+	}
+	return ""
+}
+
+func isValidRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("-_.:", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var data [16]byte
+	_, _ = rand.Read(data[:])
+	return hex.EncodeToString(data[:])
+}
@@ -0,0 +1,89 @@
+package router
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithRequestID(t *testing.T) {
+	r := NewRouter().WithOptions(WithRequestID())
+
+	var handlerID string
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		handlerID = ctx.RequestID()
+		assert.Equal(t, handlerID, RequestIDFromContext(ctx.Context()))
+		if req.UserID == 404 {
+			return userGetResponse{}, NotFound("user not found")
+		}
+		return userGetResponse{}, nil
+	})
+
+	serve := func(url string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+		return writer
+	}
+
+	t.Run("incoming header", func(t *testing.T) {
+		writer := serve("/api/users/123", http.Header{"X-Request-Id": {"req-01"}})
+		assert.Equal(t, "req-01", handlerID)
+		assert.Equal(t, "req-01", writer.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("traceparent", func(t *testing.T) {
+		writer := serve("/api/users/123", http.Header{
+			"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		})
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", handlerID)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", writer.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("invalid traceparent", func(t *testing.T) {
+		writer := serve("/api/users/123", http.Header{
+			"Traceparent": {"00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		})
+		assert.Equal(t, 32, len(handlerID))
+		assert.NotEqual(t, "00000000000000000000000000000000", handlerID)
+		assert.Equal(t, handlerID, writer.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("generated", func(t *testing.T) {
+		writer := serve("/api/users/123", http.Header{"X-Request-Id": {"invalid id <script>"}})
+		assert.Equal(t, 32, len(handlerID))
+		assert.Equal(t, handlerID, writer.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("in error body", func(t *testing.T) {
+		writer := serve("/api/users/404", http.Header{"X-Request-Id": {"req-02"}})
+		assert.Equal(t, http.StatusNotFound, writer.Code)
+		assert.Equal(t,
+			`{"error":"user not found","code":"not_found","request_id":"req-02"}`+"\n",
+			writer.Body.String(),
+		)
+	})
+}
+
+func TestRequestID_Without_Option(t *testing.T) {
+	r := NewRouter()
+
+	var handlerID string
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		handlerID = ctx.RequestID()
+		return userGetResponse{}, NotFound("user not found")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	req.Header.Set("X-Request-ID", "req-01\r\n<script>")
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, "", handlerID)
+	assert.Equal(t, "", writer.Header().Get("X-Request-ID"))
+	assert.Equal(t, `{"error":"user not found","code":"not_found"}`+"\n", writer.Body.String())
+}
@@ -0,0 +1,199 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"learn-gin/pkg/urls"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+// Responder is implemented by the response values that write the response themselves,
+// instead of being encoded as JSON (api routes) or written as html (html routes)
+type Responder interface {
+	Respond(ctx Context) error
+}
+
+var responderType = reflect.TypeFor[Responder]()
+
+func isResponderType(typ reflect.Type) bool {
+	return typ.Implements(responderType)
+}
+
+// RedirectResponse redirects the client to another url
+type RedirectResponse struct {
+	URL    string
+	Status int
+
+	err error
+}
+
+// Redirect returns a response redirecting to the url of the path evaluated with the params.
+// The status is 302 Found for GET and HEAD requests and 303 See Other for the other methods.
+// An error evaluating the path is returned when the response is written.
+func Redirect[T any](path urls.Path[T], params T) RedirectResponse {
+	url, err := path.TryEval(params)
+	return RedirectResponse{URL: url, err: err}
+}
+
+// RedirectURL returns a response redirecting to an arbitrary url
+func RedirectURL(url string) RedirectResponse {
+	return RedirectResponse{URL: url}
+}
+
+// WithStatus returns a copy of the redirect using another 3xx status
+func (r RedirectResponse) WithStatus(status int) RedirectResponse {
+	r.Status = status
+	return r
+}
+
+func (r RedirectResponse) Respond(ctx Context) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusSeeOther
+		if ctx.request.Method == http.MethodGet || ctx.request.Method == http.MethodHead {
+			status = http.StatusFound
+		}
+	}
+	http.Redirect(ctx.writer, ctx.request, r.URL, status)
+	return nil
+}
+
+// FileResponse writes the content of a file, supporting range and conditional requests
+type FileResponse struct {
+	path       string
+	name       string
+	attachment bool
+}
+
+// ServeFile returns a response writing the file at the path, the content type is detected from its extension
+func ServeFile(path string) FileResponse {
+	return FileResponse{path: path, name: filepath.Base(path)}
+}
+
+// Attachment returns a response writing the file at the path as a download named by the name
+func Attachment(path string, name string) FileResponse {
+	return FileResponse{path: path, name: name, attachment: true}
+}
+
+func (r FileResponse) Respond(ctx Context) error {
+	file, err := os.Open(r.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return NotFound("file not found").WithCause(err)
+		}
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if stat.IsDir() {
+		return NotFound("file not found")
+	}
+
+	if r.attachment {
+		setContentDisposition(ctx, "attachment", r.name)
+	}
+	http.ServeContent(ctx.writer, ctx.request, r.name, stat.ModTime(), file)
+	return nil
+}
+
+func setContentDisposition(ctx Context, disposition string, name string) {
+	value := mime.FormatMediaType(disposition, map[string]string{"filename": name})
+	if len(value) == 0 {
+		value = disposition
+	}
+	ctx.writer.Header().Set("Content-Disposition", value)
+}
+
+// BytesResponse writes a pre-encoded payload
+type BytesResponse struct {
+	ContentType string
+	Data        []byte
+}
+
+// Bytes returns a response writing the data with the content type,
+// the status is the one set by Context.SetStatusCode or 200
+func Bytes(contentType string, data []byte) BytesResponse {
+	return BytesResponse{ContentType: contentType, Data: data}
+}
+
+func (r BytesResponse) Respond(ctx Context) error {
+	ctx.writer.Header().Set("Content-Type", r.ContentType)
+	status := ctx.successStatus()
+	ctx.writer.WriteHeader(status)
+	if bodyAllowed(status) {
+		_, _ = ctx.writer.Write(r.Data)
+	}
+	return nil
+}
+
+// StreamResponse writes the body with a function, e.g. for large exports
+type StreamResponse struct {
+	ContentType string
+	Name        string
+	Write       func(w io.Writer) error
+}
+
+// Stream returns a response writing the body with the function after the headers are sent,
+// an error returned by the function can only abort the body
+func Stream(contentType string, write func(w io.Writer) error) StreamResponse {
+	return StreamResponse{ContentType: contentType, Write: write}
+}
+
+// AsAttachment returns a copy of the stream downloaded as a file with the name
+func (r StreamResponse) AsAttachment(name string) StreamResponse {
+	r.Name = name
+	return r
+}
+
+func (r StreamResponse) Respond(ctx Context) error {
+	ctx.writer.Header().Set("Content-Type", r.ContentType)
+	if len(r.Name) > 0 {
+		setContentDisposition(ctx, "attachment", r.Name)
+	}
+	ctx.writer.WriteHeader(ctx.successStatus())
+	if err := r.Write(ctx.writer); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	return nil
+}
+
+// NoContentResponse writes a 204 response without body
+type NoContentResponse struct{}
+
+// NoContent returns a response with status 204 and without body
+func NoContent() NoContentResponse {
+	return NoContentResponse{}
+}
+
+func (NoContentResponse) Respond(ctx Context) error {
+	ctx.writer.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// Respond makes the response written by the responder instead of the value returned by the handler,
+// it is mostly useful for html handlers, e.g. to redirect after a form submission
+func (c Context) Respond(responder Responder) {
+	c.state.responder = responder
+}
+
+// responderOf returns the responder that takes over writing the response, if any
+func responderOf(ctx Context, respBody any) (Responder, bool) {
+	if ctx.state.responder != nil {
+		return ctx.state.responder, true
+	}
+	responder, ok := respBody.(Responder)
+	return responder, ok
+}
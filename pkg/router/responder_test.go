@@ -0,0 +1,119 @@
+package router
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAPIPost_Redirect(t *testing.T) {
+	r := NewRouter()
+
+	APIPost(r, userPath, func(ctx Context, req userPostRequest) (RedirectResponse, error) {
+		return Redirect(userPath, userParams{UserID: req.UserID, Search: "new"}), nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", bytes.NewBufferString(`{}`))
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusSeeOther, writer.Code)
+	assert.Equal(t, "/api/users/123?search=new", writer.Header().Get("Location"))
+}
+
+func TestHTMLPost_Redirect_With_Context_Respond(t *testing.T) {
+	r := NewRouter()
+
+	HTMLPost(r, userPath, func(ctx Context, req userPostRequest) (template.HTML, error) {
+		ctx.Respond(RedirectURL("/done").WithStatus(http.StatusFound))
+		return "", nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", bytes.NewBufferString(`{}`))
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusFound, writer.Code)
+	assert.Equal(t, "/done", writer.Header().Get("Location"))
+}
+
+func TestAPIGet_Attachment(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "report.csv")
+	assert.Equal(t, nil, os.WriteFile(filePath, []byte("a,b\n1,2\n"), 0o600))
+
+	r := NewRouter()
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (Responder, error) {
+		if req.UserID == 404 {
+			return ServeFile(filepath.Join(dir, "missing.csv")), nil
+		}
+		return Attachment(filePath, "users 2024.csv"), nil
+	})
+
+	t.Run("normal", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, "a,b\n1,2\n", writer.Body.String())
+		assert.Equal(t, "text/csv; charset=utf-8", writer.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="users 2024.csv"`, writer.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/users/404", nil)
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+
+		assert.Equal(t, http.StatusNotFound, writer.Code)
+		assert.Equal(t, `{"error":"file not found","code":"not_found"}`+"\n", writer.Body.String())
+	})
+}
+
+func TestAPIGet_Bytes_Through_Middleware(t *testing.T) {
+	r := NewRouter().WithMiddlewares(func(handler GenericHandler) GenericHandler {
+		return func(ctx Context, req any) (any, error) {
+			resp, err := handler(ctx, req)
+			if b, ok := resp.(BytesResponse); ok {
+				b.Data = append(b.Data, []byte(" world")...)
+				return b, err
+			}
+			return resp, err
+		}
+	})
+
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (BytesResponse, error) {
+		ctx.SetStatusCode(http.StatusAccepted)
+		return Bytes("text/plain", []byte("hello")), nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusAccepted, writer.Code)
+	assert.Equal(t, "hello world", writer.Body.String())
+	assert.Equal(t, "text/plain", writer.Header().Get("Content-Type"))
+}
+
+func TestAPIDelete_NoContent_Responder(t *testing.T) {
+	r := NewRouter()
+
+	APIDelete(r, userPath, func(ctx Context, req userGetRequest) (NoContentResponse, error) {
+		return NoContent(), nil
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusNoContent, writer.Code)
+	assert.Equal(t, "", writer.Body.String())
+	assert.Equal(t, http.Header{}, writer.Header())
+}
@@ -4,54 +4,139 @@ import (
 	"encoding/json"
 	"learn-gin/pkg/urls"
 	"net/http"
+	"reflect"
 )
 
 func APIGet[T any, Req any, Resp any](
 	r *Router, pattern urls.Path[T],
 	handler func(ctx Context, req Req) (Resp, error),
+	opts ...Option,
 ) {
-	apiDoAction(r, r.mux.Get, false, pattern, handler)
+	apiDoAction(r, http.MethodGet, pattern, handler, opts)
 }
 
 func APIPost[T any, Req any, Resp any](
 	r *Router, pattern urls.Path[T],
 	handler func(ctx Context, req Req) (Resp, error),
+	opts ...Option,
 ) {
-	apiDoAction(r, r.mux.Post, true, pattern, handler)
+	apiDoAction(r, http.MethodPost, pattern, handler, opts)
+}
+
+func APIPut[T any, Req any, Resp any](
+	r *Router, pattern urls.Path[T],
+	handler func(ctx Context, req Req) (Resp, error),
+	opts ...Option,
+) {
+	apiDoAction(r, http.MethodPut, pattern, handler, opts)
+}
+
+func APIPatch[T any, Req any, Resp any](
+	r *Router, pattern urls.Path[T],
+	handler func(ctx Context, req Req) (Resp, error),
+	opts ...Option,
+) {
+	apiDoAction(r, http.MethodPatch, pattern, handler, opts)
+}
+
+// APIDelete decodes the JSON body only when the request has one
+func APIDelete[T any, Req any, Resp any](
+	r *Router, pattern urls.Path[T],
+	handler func(ctx Context, req Req) (Resp, error),
+	opts ...Option,
+) {
+	apiDoAction(r, http.MethodDelete, pattern, handler, opts)
+}
+
+// APIHead overrides the HEAD handler that is registered automatically by APIGet
+func APIHead[T any, Req any, Resp any](
+	r *Router, pattern urls.Path[T],
+	handler func(ctx Context, req Req) (Resp, error),
+	opts ...Option,
+) {
+	apiDoAction(r, http.MethodHead, pattern, handler, opts)
+}
+
+// APIOptions overrides the OPTIONS handler that is registered automatically for every pattern
+func APIOptions[T any, Req any, Resp any](
+	r *Router, pattern urls.Path[T],
+	handler func(ctx Context, req Req) (Resp, error),
+	opts ...Option,
+) {
+	apiDoAction(r, http.MethodOptions, pattern, handler, opts)
+}
+
+// APIMethod registers an api handler for an arbitrary http method
+func APIMethod[T any, Req any, Resp any](
+	r *Router, method string, pattern urls.Path[T],
+	handler func(ctx Context, req Req) (Resp, error),
+	opts ...Option,
+) {
+	apiDoAction(r, method, pattern, handler, opts)
 }
 
 func apiDoAction[T any, Req any, Resp any](
 	r *Router,
-	registerFunc func(pattern string, handler http.HandlerFunc),
-	decodeBody bool,
+	method string,
 	pattern urls.Path[T],
 	handler func(ctx Context, req Req) (Resp, error),
+	opts []Option,
 ) {
+	r = r.WithOptions(opts...)
+
 	var testPathVal T
 	var testReqVal Req
 	urls.CheckIsSubStruct(testReqVal, testPathVal)
+	r.checkRequestType(testReqVal)
+	checkValidation(testReqVal)
+
+	path := newRoutePath(r, pattern)
+	plan := newBindingPlan(reflect.TypeFor[Req](), path)
+	var formPlan *bindingPlan
+	if r.config.formBody {
+		formPlan = newFormPlan(reflect.TypeFor[Req]())
+	}
 
 	genericHandler := func(ctx Context, req any) (resp any, err error) {
 		return handler(ctx, req.(Req))
 	}
 	genericHandler = r.wrapHandler(genericHandler) // TODO Testing
 
-	registerFunc(pattern.GetPattern(), func(writer http.ResponseWriter, request *http.Request) {
+	rule := bodyRuleOf(method)
+
+	info := newRouteInfo[Req, Resp](RouteKindAPI, method, path, r.config.formBody)
+	r.register(info, func(writer http.ResponseWriter, request *http.Request) {
+		request, csrfErr := r.issueCSRF(writer, request)
 		ctx := NewContext(writer, request)
-		var req Req
+		if csrfErr != nil {
+			r.writeAPIResp(ctx, nil, csrfErr, http.StatusInternalServerError)
+			return
+		}
+
+		if err := r.verifyCSRF(request, info); err != nil {
+			r.writeAPIResp(ctx, nil, err, http.StatusForbidden)
+			return
+		}
 
-		if decodeBody {
-			if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
-				r.writeAPIResp(ctx, nil, err, http.StatusBadRequest)
-				return
-			}
+		var req Req
+		defer removeMultipartFiles(request)
+		if err := r.decodeBody(request, rule, formPlan, &req); err != nil {
+			r.writeAPIResp(ctx, nil, err, http.StatusBadRequest)
+			return
 		}
 
-		if err := doAssignParams(ctx, &req, pattern); err != nil {
+		if err := plan.bind(request, &req); err != nil {
 			r.writeAPIResp(ctx, nil, err, http.StatusBadRequest)
 			return
 		}
 
+		ctx.state.req = req
+
+		if err := validateRequest(&req); err != nil {
+			r.writeAPIResp(ctx, nil, err, http.StatusUnprocessableEntity)
+			return
+		}
+
 		respBody, err := genericHandler(ctx, req)
 		r.writeAPIResp(ctx, respBody, err, http.StatusInternalServerError)
 	})
@@ -59,18 +144,29 @@ func apiDoAction[T any, Req any, Resp any](
 
 func (r *Router) writeAPIResp(ctx Context, respBody any, err error, status int) {
 	writer := ctx.writer
-	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
 
 	if err != nil {
-		if ctx.state.code != 0 {
-			status = ctx.state.code
+		status, errBody := r.resolveError(ctx, err, status)
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(status)
+		_ = json.NewEncoder(writer).Encode(errBody)
+		return
+	}
+
+	if responder, ok := responderOf(ctx, respBody); ok {
+		if err := responder.Respond(ctx); err != nil {
+			r.writeAPIResp(ctx, nil, err, http.StatusInternalServerError)
 		}
+		return
+	}
+
+	status = ctx.successStatus()
+	if !bodyAllowed(status) {
 		writer.WriteHeader(status)
-		_ = json.NewEncoder(writer).Encode(ErrorBody{
-			Error: err.Error(),
-		})
 		return
 	}
 
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(status)
 	_ = json.NewEncoder(writer).Encode(respBody)
 }
@@ -79,7 +79,7 @@ func TestAPIGet_With_Error(t *testing.T) {
 	}, inputReq)
 
 	assert.Equal(t, http.StatusInternalServerError, writer.Code)
-	assert.Equal(t, `{"error":"some handler error"}`+"\n", writer.Body.String())
+	assert.Equal(t, `{"error":"internal server error","code":"internal_error"}`+"\n", writer.Body.String())
 	assert.Equal(t, http.Header{
 		"Content-Type": []string{"application/json; charset=utf-8"},
 	}, writer.Header())
@@ -180,7 +180,8 @@ func TestAPIPost_Parse_JSON_Request_Error(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, writer.Code)
 	assert.Equal(t,
-		`{"error":"json: cannot unmarshal string into Go struct field userSetting.setting.count of type int"}`+"\n",
+		`{"error":"invalid request body","code":"invalid_json","fields":[`+
+			`{"field":"Setting.Count","path":"setting.count","rule":"type","message":"expected number, got string"}]}`+"\n",
 		writer.Body.String(),
 	)
 	assert.Equal(t, http.Header{
@@ -267,3 +268,301 @@ func TestAPIGet_Success_With_Middlewares_Return_Error(t *testing.T) {
 		"Content-Type": []string{"application/json; charset=utf-8"},
 	}, writer.Header())
 }
+
+func TestAPIPut_Success(t *testing.T) {
+	r := NewRouter()
+
+	var inputReq userPostRequest
+	APIPut(r, userPath, func(ctx Context, req userPostRequest) (userGetResponse, error) {
+		inputReq = req
+		return userGetResponse{
+			UserID:   req.UserID,
+			Username: req.Body,
+		}, nil
+	})
+
+	body := `{"body": "new body", "search": "text"}`
+
+	req := httptest.NewRequest(http.MethodPut, "/api/users/123?age=21", bytes.NewBufferString(body))
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, userPostRequest{
+		UserID: 123,
+		Search: "text",
+		Age:    21,
+		Body:   "new body",
+	}, inputReq)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, `{"user_id":123,"username":"new body"}`+"\n", writer.Body.String())
+}
+
+func TestAPIPatch_Missing_Body(t *testing.T) {
+	r := NewRouter()
+
+	APIPatch(r, userPath, func(ctx Context, req userPostRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusBadRequest, writer.Code)
+	assert.Equal(t, `{"error":"request body is empty","code":"empty_body"}`+"\n", writer.Body.String())
+}
+
+func TestAPIDelete(t *testing.T) {
+	t.Run("without body", func(t *testing.T) {
+		r := NewRouter()
+
+		var inputReq userPostRequest
+		APIDelete(r, userPath, func(ctx Context, req userPostRequest) (userGetResponse, error) {
+			inputReq = req
+			return userGetResponse{UserID: req.UserID}, nil
+		})
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/users/123", nil)
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+
+		assert.Equal(t, userPostRequest{UserID: 123}, inputReq)
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, `{"user_id":123,"username":""}`+"\n", writer.Body.String())
+	})
+
+	t.Run("with body", func(t *testing.T) {
+		r := NewRouter()
+
+		var inputReq userPostRequest
+		APIDelete(r, userPath, func(ctx Context, req userPostRequest) (userGetResponse, error) {
+			inputReq = req
+			return userGetResponse{UserID: req.UserID}, nil
+		})
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/users/123", bytes.NewBufferString(`{"body":"reason"}`))
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+
+		assert.Equal(t, userPostRequest{UserID: 123, Body: "reason"}, inputReq)
+		assert.Equal(t, http.StatusOK, writer.Code)
+	})
+}
+
+func TestAPIGet_Automatic_Head(t *testing.T) {
+	r := NewRouter()
+
+	calls := 0
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		calls++
+		return userGetResponse{UserID: req.UserID}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodHead, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "", writer.Body.String())
+	assert.Equal(t, http.Header{
+		"Content-Type": []string{"application/json; charset=utf-8"},
+	}, writer.Header())
+}
+
+func TestAPIHead_Explicit(t *testing.T) {
+	r := NewRouter()
+
+	var steps []string
+	APIHead(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		steps = append(steps, "head")
+		return userGetResponse{}, nil
+	})
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		steps = append(steps, "get")
+		return userGetResponse{}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodHead, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, []string{"head"}, steps)
+	assert.Equal(t, "", writer.Body.String())
+}
+
+func TestAPI_Automatic_Options(t *testing.T) {
+	r := NewRouter()
+
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+	APIPost(r, userPath, func(ctx Context, req userPostRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+	APIDelete(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusNoContent, writer.Code)
+	assert.Equal(t, "", writer.Body.String())
+	assert.Equal(t, http.Header{
+		"Allow": []string{"DELETE, GET, HEAD, OPTIONS, POST"},
+	}, writer.Header())
+
+	req = httptest.NewRequest(http.MethodPut, "/api/users/123", nil)
+	writer = httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, writer.Code)
+}
+
+func TestAPI_HTTP_Middlewares_Of_Automatic_Routes(t *testing.T) {
+	var wrapped, served []string
+	r := NewRouter().WithOptions(WithRequestID()).WithHTTPMiddlewares(
+		func(info RouteInfo, next http.Handler) http.Handler {
+			wrapped = append(wrapped, info.Method)
+			return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				served = append(served, request.Method)
+				next.ServeHTTP(writer, request)
+			})
+		},
+	)
+
+	APIPost(r, userPath, func(ctx Context, req userPostRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+	assert.Equal(t, []string{http.MethodPost, http.MethodOptions}, wrapped)
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusNoContent, writer.Code)
+	assert.Equal(t, []string{http.MethodOptions}, served)
+	assert.NotEqual(t, "", writer.Header().Get(RequestIDHeader))
+}
+
+func TestAPIOptions_Explicit(t *testing.T) {
+	r := NewRouter()
+
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		return userGetResponse{}, nil
+	})
+	APIOptions(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		return userGetResponse{Username: "options"}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, `{"user_id":0,"username":"options"}`+"\n", writer.Body.String())
+}
+
+type userHeaderRequest struct {
+	UserID   userID `json:"user_id"`
+	Search   string `json:"search"`
+	Age      int    `json:"age"`
+	Body     string `json:"body"`
+	TenantID string `json:"tenant_id" header:"X-Tenant-ID"`
+	Session  string `json:"-" cookie:"session"`
+}
+
+func TestAPIPost_With_Header_And_Cookie(t *testing.T) {
+	r := NewRouter()
+
+	var inputReq userHeaderRequest
+	APIPost(r, userPath, func(ctx Context, req userHeaderRequest) (userGetResponse, error) {
+		inputReq = req
+		return userGetResponse{}, nil
+	})
+
+	body := `{"body": "Some Body", "tenant_id": "from body"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", bytes.NewBufferString(body))
+	req.Header.Set("X-Tenant-ID", "tenant01")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "session-value"})
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, userHeaderRequest{
+		UserID:   123,
+		Body:     "Some Body",
+		TenantID: "tenant01",
+		Session:  "session-value",
+	}, inputReq)
+
+	routes := r.Routes()
+	assert.Equal(t, []string{"X-Tenant-ID"}, routes[0].Headers)
+	assert.Equal(t, []string{"session"}, routes[0].Cookies)
+}
+
+func TestAPIPost_Custom_Status_Headers_And_Cookies(t *testing.T) {
+	r := NewRouter()
+
+	APIPost(r, userPath, func(ctx Context, req userPostRequest) (userGetResponse, error) {
+		ctx.SetStatusCode(http.StatusCreated)
+		ctx.SetHeader("Location", "/api/users/123")
+		ctx.SetCookie(&http.Cookie{Name: "session", Value: "sess01", Path: "/"})
+		ctx.SetCacheControl("no-store")
+		return userGetResponse{UserID: 123}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", bytes.NewBufferString(`{}`))
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusCreated, writer.Code)
+	assert.Equal(t, `{"user_id":123,"username":""}`+"\n", writer.Body.String())
+	assert.Equal(t, http.Header{
+		"Content-Type":  []string{"application/json; charset=utf-8"},
+		"Location":      []string{"/api/users/123"},
+		"Set-Cookie":    []string{"session=sess01; Path=/"},
+		"Cache-Control": []string{"no-store"},
+	}, writer.Header())
+}
+
+func TestAPIDelete_No_Content(t *testing.T) {
+	r := NewRouter()
+
+	APIDelete(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		ctx.SetStatusCode(http.StatusNoContent)
+		ctx.SetETag("v2")
+		return userGetResponse{}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusNoContent, writer.Code)
+	assert.Equal(t, "", writer.Body.String())
+	assert.Equal(t, http.Header{
+		"Etag": []string{`"v2"`},
+	}, writer.Header())
+}
+
+func TestAPIGet_Headers_Kept_On_Error(t *testing.T) {
+	r := NewRouter()
+
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		ctx.SetHeader("Retry-After", "30")
+		ctx.SetStatusCode(http.StatusServiceUnavailable)
+		return userGetResponse{}, errors.New("maintenance")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, writer.Code)
+	assert.Equal(t, `{"error":"internal server error","code":"internal_error"}`+"\n", writer.Body.String())
+	assert.Equal(t, "30", writer.Header().Get("Retry-After"))
+}
@@ -2,19 +2,25 @@ package router
 
 import (
 	"github.com/go-chi/chi/v5"
+	"net/http"
 	"slices"
 )
 
 type Router struct {
 	mux         *chi.Mux
 	middlewares []MiddlewareFunc
+	httpMws     []HTTPMiddleware
 	wrapFunc    func(handler GenericHandler) GenericHandler
+	registry    *routeRegistry
+	prefix      routePrefix
+	config      routerConfig
 }
 
 func NewRouter() *Router {
 	mux := chi.NewRouter()
 	return &Router{
-		mux: mux,
+		mux:      mux,
+		registry: newRouteRegistry(),
 	}
 }
 
@@ -31,6 +37,21 @@ func (r *Router) WithMiddlewares(
 	return &newR
 }
 
+// HTTPMiddleware wraps the http handler of a route, it is applied when the route is registered,
+// so the metadata of the route is available, e.g. for logging by route pattern
+type HTTPMiddleware = func(info RouteInfo, next http.Handler) http.Handler
+
+// WithHTTPMiddlewares returns a new router sharing the same mux
+// with the http middlewares applied to the routes registered after
+func (r *Router) WithHTTPMiddlewares(
+	middlewares ...HTTPMiddleware,
+) *Router {
+	newR := *r
+	newR.httpMws = slices.Clone(r.httpMws)
+	newR.httpMws = append(newR.httpMws, middlewares...)
+	return &newR
+}
+
 func (r *Router) Mux() *chi.Mux {
 	return r.mux
 }
@@ -39,9 +60,31 @@ func (r *Router) wrapHandler(handler GenericHandler) GenericHandler {
 	for i := len(r.middlewares) - 1; i >= 0; i-- {
 		handler = r.middlewares[i](handler)
 	}
-	return handler
+	return r.recoverHandler(handler)
 }
 
-type ErrorBody struct {
-	Error string `json:"error"`
+func (r *Router) wrapHTTPHandler(info RouteInfo, handler http.HandlerFunc) http.HandlerFunc {
+	if len(r.httpMws) > 0 {
+		// recovered inside the http middlewares too, so that they observe the internal error of a panic
+		var h http.Handler = r.recoverHTTPHandler(info, handler)
+		for i := len(r.httpMws) - 1; i >= 0; i-- {
+			h = r.httpMws[i](info, h)
+		}
+		handler = func(writer http.ResponseWriter, request *http.Request) {
+			h.ServeHTTP(writer, withRequestState(request, &requestState{}))
+		}
+	}
+
+	if r.config.cors != nil {
+		handler = corsHandler(r.config.cors, handler)
+	}
+	if r.config.tracer != nil {
+		handler = tracingHandler(r.config.tracer, info, handler)
+	}
+	// recovers the panics of the http middlewares, the request id handler is outside so that the id is in the reports
+	handler = r.recoverHTTPHandler(info, handler)
+	if r.config.requestID {
+		handler = requestIDHandler(handler)
+	}
+	return handler
 }
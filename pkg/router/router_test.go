@@ -79,6 +79,7 @@ func TestHTMLGet_With_Error(t *testing.T) {
 		userPath.Eval(userParams{UserID: 555, Search: "<div>hello</div>"}),
 		nil,
 	)
+	req.Header.Set("Accept", "application/json")
 	writer := httptest.NewRecorder()
 	r.Mux().ServeHTTP(writer, req)
 
@@ -88,7 +89,7 @@ func TestHTMLGet_With_Error(t *testing.T) {
 	}, inputReq)
 
 	assert.Equal(t, http.StatusInternalServerError, writer.Code)
-	assert.Equal(t, `{"error":"some handler error"}`+"\n", writer.Body.String())
+	assert.Equal(t, `{"error":"internal server error","code":"internal_error"}`+"\n", writer.Body.String())
 	assert.Equal(t, http.Header{
 		"Content-Type": []string{"application/json; charset=utf-8"},
 	}, writer.Header())
@@ -106,6 +107,7 @@ func TestHTMLGet_With_Invalid_Query_Param(t *testing.T) {
 		"/api/users/123?age=AB",
 		nil,
 	)
+	req.Header.Set("Accept", "application/json")
 	writer := httptest.NewRecorder()
 	r.Mux().ServeHTTP(writer, req)
 
@@ -194,12 +196,14 @@ func TestHTMLPost_Parse_JSON_Request_Error(t *testing.T) {
 `
 
 	req := httptest.NewRequest(http.MethodPost, "/api/users/123", bytes.NewBufferString(body))
+	req.Header.Set("Accept", "application/json")
 	writer := httptest.NewRecorder()
 	r.Mux().ServeHTTP(writer, req)
 
 	assert.Equal(t, http.StatusBadRequest, writer.Code)
 	assert.Equal(t,
-		`{"error":"json: cannot unmarshal string into Go struct field userSetting.setting.count of type int"}`+"\n",
+		`{"error":"invalid request body","code":"invalid_json","fields":[`+
+			`{"field":"Setting.Count","path":"setting.count","rule":"type","message":"expected number, got string"}]}`+"\n",
 		writer.Body.String(),
 	)
 	assert.Equal(t, http.Header{
@@ -222,6 +226,7 @@ func TestHTMLPost_Parse_Query_Param_Error(t *testing.T) {
 `
 
 	req := httptest.NewRequest(http.MethodPost, "/api/users/123?age=AA", bytes.NewBufferString(body))
+	req.Header.Set("Accept", "application/json")
 	writer := httptest.NewRecorder()
 	r.Mux().ServeHTTP(writer, req)
 
@@ -234,3 +239,64 @@ func TestHTMLPost_Parse_Query_Param_Error(t *testing.T) {
 		"Content-Type": []string{"application/json; charset=utf-8"},
 	}, writer.Header())
 }
+
+func TestHTMLPut_Success(t *testing.T) {
+	r := NewRouter()
+
+	var inputReq userPostRequest
+	HTMLPut(r, userPath, func(ctx Context, req userPostRequest) (template.HTML, error) {
+		inputReq = req
+		return "<div>Updated</div>", nil
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/api/users/123", bytes.NewBufferString(`{"body":"Some Body"}`))
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, userPostRequest{
+		UserID: 123,
+		Body:   "Some Body",
+	}, inputReq)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "<div>Updated</div>", writer.Body.String())
+}
+
+func TestHTMLGet_Automatic_Head(t *testing.T) {
+	r := NewRouter()
+
+	HTMLGet(r, userPath, func(ctx Context, req userGetRequest) (template.HTML, error) {
+		return "<div>Hello</div>", nil
+	})
+
+	req := httptest.NewRequest(http.MethodHead, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "", writer.Body.String())
+	assert.Equal(t, http.Header{
+		"Content-Type": []string{"text/html; charset=utf-8"},
+	}, writer.Header())
+}
+
+func TestHTMLPost_Custom_Status_And_Cookie(t *testing.T) {
+	r := NewRouter()
+
+	HTMLPost(r, userPath, func(ctx Context, req userPostRequest) (template.HTML, error) {
+		ctx.SetStatusCode(http.StatusCreated)
+		ctx.SetCookie(&http.Cookie{Name: "flash", Value: "created"})
+		return "<div>created</div>", nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", bytes.NewBufferString(`{}`))
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusCreated, writer.Code)
+	assert.Equal(t, "<div>created</div>", writer.Body.String())
+	assert.Equal(t, http.Header{
+		"Content-Type": []string{"text/html; charset=utf-8"},
+		"Set-Cookie":   []string{"flash=created"},
+	}, writer.Header())
+}
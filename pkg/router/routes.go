@@ -0,0 +1,274 @@
+package router
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type bodyRule int
+
+const (
+	bodyNone bodyRule = iota
+	bodyOptional
+	bodyRequired
+)
+
+func bodyRuleOf(method string) bodyRule {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return bodyRequired
+	case http.MethodDelete:
+		return bodyOptional
+	default:
+		return bodyNone
+	}
+}
+
+type RouteKind int
+
+const (
+	RouteKindAPI RouteKind = iota + 1
+	RouteKindHTML
+	RouteKindPage
+	RouteKindSSE
+	// RouteKindDocs serves the OpenAPI document and the docs page, it is not part of the document
+	RouteKindDocs
+)
+
+// RouteInfo is the metadata of a registered route
+type RouteInfo struct {
+	Kind       RouteKind
+	Method     string
+	Pattern    string
+	PathParams []string
+	AllParams  []string
+	Headers    []string
+	Cookies    []string
+	Request    reflect.Type
+	Response   reflect.Type
+	FormBody   bool
+}
+
+// QueryParams returns the params that are not path params
+func (info RouteInfo) QueryParams() []string {
+	var result []string
+	for _, p := range info.AllParams {
+		if !slices.Contains(info.PathParams, p) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// HasBody returns true if the request body is decoded for the method of the route
+func (info RouteInfo) HasBody() bool {
+	return bodyRuleOf(info.Method) != bodyNone
+}
+
+func newRouteInfo[Req any, Resp any](kind RouteKind, method string, path routePath, formBody bool) RouteInfo {
+	var headers, cookies []string
+	for _, f := range headerFields(reflect.TypeFor[Req]()) {
+		if in, name := headerParamOf(f); in == "header" {
+			headers = append(headers, name)
+		} else {
+			cookies = append(cookies, name)
+		}
+	}
+
+	return RouteInfo{
+		Kind:       kind,
+		Method:     method,
+		Pattern:    path.pattern,
+		PathParams: path.pathParams,
+		AllParams:  path.allParams,
+		Headers:    headers,
+		Cookies:    cookies,
+		Request:    reflect.TypeFor[Req](),
+		Response:   reflect.TypeFor[Resp](),
+		FormBody:   formBody,
+	}
+}
+
+type patternMethods struct {
+	methods      []string
+	explicitHead bool
+	cors         map[string]*corsPolicy
+}
+
+type mountedRegistry struct {
+	prefix   routePrefix
+	registry *routeRegistry
+}
+
+type routeRegistry struct {
+	mut      sync.RWMutex
+	patterns map[string]*patternMethods
+	routes   []RouteInfo
+	mounts   []mountedRegistry
+}
+
+func newRouteRegistry() *routeRegistry {
+	return &routeRegistry{
+		patterns: map[string]*patternMethods{},
+	}
+}
+
+// add records the route and returns true if this is the first method of the pattern
+func (reg *routeRegistry) add(info RouteInfo, cors *corsPolicy) (*patternMethods, bool) {
+	reg.mut.Lock()
+	defer reg.mut.Unlock()
+
+	reg.routes = append(reg.routes, info)
+
+	pattern, method := info.Pattern, info.Method
+
+	entry, existed := reg.patterns[pattern]
+	if !existed {
+		entry = &patternMethods{}
+		reg.patterns[pattern] = entry
+	}
+
+	if method == http.MethodHead {
+		entry.explicitHead = true
+	}
+	if !slices.Contains(entry.methods, method) {
+		entry.methods = append(entry.methods, method)
+	}
+	if cors != nil {
+		if entry.cors == nil {
+			entry.cors = map[string]*corsPolicy{}
+		}
+		entry.cors[method] = cors
+	}
+	return entry, !existed
+}
+
+func (reg *routeRegistry) mount(prefix routePrefix, sub *routeRegistry) {
+	reg.mut.Lock()
+	defer reg.mut.Unlock()
+
+	reg.mounts = append(reg.mounts, mountedRegistry{
+		prefix:   prefix,
+		registry: sub,
+	})
+}
+
+func (reg *routeRegistry) allowedMethods(pattern string) string {
+	reg.mut.RLock()
+	defer reg.mut.RUnlock()
+
+	entry, ok := reg.patterns[pattern]
+	if !ok {
+		return http.MethodOptions
+	}
+
+	methods := slices.Clone(entry.methods)
+	if slices.Contains(methods, http.MethodGet) && !slices.Contains(methods, http.MethodHead) {
+		methods = append(methods, http.MethodHead)
+	}
+	if !slices.Contains(methods, http.MethodOptions) {
+		methods = append(methods, http.MethodOptions)
+	}
+	slices.Sort(methods)
+	return strings.Join(methods, ", ")
+}
+
+// corsPolicy returns the CORS policy of the route of the method, nil if none
+func (reg *routeRegistry) corsPolicy(pattern string, method string) *corsPolicy {
+	reg.mut.RLock()
+	defer reg.mut.RUnlock()
+
+	entry, ok := reg.patterns[pattern]
+	if !ok {
+		return nil
+	}
+	policy, ok := entry.cors[method]
+	if !ok && method == http.MethodHead && !entry.explicitHead {
+		policy = entry.cors[http.MethodGet]
+	}
+	return policy
+}
+
+func (reg *routeRegistry) allRoutes() []RouteInfo {
+	reg.mut.RLock()
+	defer reg.mut.RUnlock()
+
+	result := slices.Clone(reg.routes)
+	for _, m := range reg.mounts {
+		for _, info := range m.registry.allRoutes() {
+			info.Pattern = joinPattern(m.prefix.pattern, info.Pattern)
+			info.PathParams = appendParams(m.prefix.pathParams, info.PathParams)
+			info.AllParams = appendParams(m.prefix.allParams, info.AllParams)
+			result = append(result, info)
+		}
+	}
+	return result
+}
+
+// Routes returns the metadata of all routes registered on the underlying mux,
+// including the routes of the mounted routers
+func (r *Router) Routes() []RouteInfo {
+	routes := r.registry.allRoutes()
+	slices.SortStableFunc(routes, func(a, b RouteInfo) int {
+		return strings.Compare(a.Pattern, b.Pattern)
+	})
+	return routes
+}
+
+// register adds the handler to the mux, together with the automatic HEAD and OPTIONS handlers
+func (r *Router) register(info RouteInfo, handler http.HandlerFunc) {
+	entry, isNew := r.registry.add(info, r.config.cors)
+
+	method, pattern := info.Method, info.Pattern
+
+	// the head handler is only wrapped when registered, so that the http middlewares see no phantom route
+	headHandler := func() http.HandlerFunc {
+		headInfo := info
+		headInfo.Method = http.MethodHead
+		return r.wrapHTTPHandler(headInfo, func(writer http.ResponseWriter, request *http.Request) {
+			handler(headResponseWriter{ResponseWriter: writer}, request)
+		})
+	}
+
+	switch {
+	case method == http.MethodHead:
+		r.mux.MethodFunc(method, pattern, headHandler())
+	case method == http.MethodGet && !entry.explicitHead && info.Kind != RouteKindSSE:
+		r.mux.MethodFunc(method, pattern, r.wrapHTTPHandler(info, handler))
+		r.mux.MethodFunc(http.MethodHead, pattern, headHandler())
+	default:
+		r.mux.MethodFunc(method, pattern, r.wrapHTTPHandler(info, handler))
+	}
+
+	if isNew && method != http.MethodOptions {
+		optionsInfo := info
+		optionsInfo.Method = http.MethodOptions
+		// the preflight is answered with the policies of all the methods of the pattern,
+		// not by the cors handler of the router registering the first method
+		optionsRouter := *r
+		optionsRouter.config.cors = nil
+		options := func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("Allow", r.registry.allowedMethods(pattern))
+			if isPreflight(request) {
+				method := strings.ToUpper(request.Header.Get("Access-Control-Request-Method"))
+				if policy := r.registry.corsPolicy(pattern, method); policy != nil {
+					writePreflight(writer, request, policy)
+					return
+				}
+			}
+			writer.WriteHeader(http.StatusNoContent)
+		}
+		r.mux.MethodFunc(http.MethodOptions, pattern, optionsRouter.wrapHTTPHandler(optionsInfo, options))
+	}
+}
+
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w headResponseWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
@@ -0,0 +1,271 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"learn-gin/pkg/urls"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LastEventIDHeader is sent by the browsers when reconnecting to an event stream,
+// it is bound to a request field tagged `header:"Last-Event-ID"`
+const LastEventIDHeader = "Last-Event-ID"
+
+const defaultSSEHeartbeat = 15 * time.Second
+
+// WithSSEHeartbeat sets the interval of the comments written to keep idle event streams open,
+// 15 seconds by default, a negative interval disables the heartbeats.
+// The heartbeats start after the first write of the handler, so that an error returned before is still
+// written as a json error response
+func WithSSEHeartbeat(interval time.Duration) Option {
+	return func(conf *routerConfig) {
+		conf.sseHeartbeat = interval
+	}
+}
+
+// ErrStreamClosed is returned by the EventSender when the client has disconnected
+var ErrStreamClosed = errors.New("router: event stream closed")
+
+// Message is a server-sent event, Data is encoded as json
+type Message[Event any] struct {
+	ID    string
+	Name  string
+	Data  Event
+	Retry time.Duration
+}
+
+// EventSender writes the events of a stream, it is safe for concurrent use
+type EventSender[Event any] struct {
+	ctx    context.Context
+	writer http.ResponseWriter
+	ctl    *http.ResponseController
+
+	mut     sync.Mutex
+	started bool
+	closed  bool
+}
+
+func newEventSender[Event any](ctx context.Context, writer http.ResponseWriter) *EventSender[Event] {
+	return &EventSender[Event]{
+		ctx:    ctx,
+		writer: writer,
+		ctl:    http.NewResponseController(writer),
+	}
+}
+
+// Send writes an event without name, received by the onmessage handler of an EventSource
+func (s *EventSender[Event]) Send(event Event) error {
+	return s.SendMessage(Message[Event]{Data: event})
+}
+
+// SendNamed writes an event received by the listeners of the name
+func (s *EventSender[Event]) SendNamed(name string, event Event) error {
+	return s.SendMessage(Message[Event]{Name: name, Data: event})
+}
+
+// SendMessage writes an event with all its fields, the ID is sent back as Last-Event-ID on reconnection
+func (s *EventSender[Event]) SendMessage(msg Message[Event]) error {
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return err
+	}
+	return s.writeEvent(msg.ID, msg.Name, data, msg.Retry)
+}
+
+// Comment writes a comment line, ignored by the clients
+func (s *EventSender[Event]) Comment(text string) error {
+	var buf strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		buf.WriteString(": ")
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+	return s.write(buf.String())
+}
+
+func (s *EventSender[Event]) writeEvent(id string, name string, data []byte, retry time.Duration) error {
+	if strings.ContainsAny(id, "\r\n\x00") {
+		return errors.New("router: invalid event id")
+	}
+	if strings.ContainsAny(name, "\r\n") {
+		return errors.New("router: invalid event name")
+	}
+
+	var buf strings.Builder
+	if len(id) > 0 {
+		buf.WriteString("id: " + id + "\n")
+	}
+	if len(name) > 0 {
+		buf.WriteString("event: " + name + "\n")
+	}
+	if retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(retry.Milliseconds(), 10) + "\n")
+	}
+	buf.WriteString("data: ")
+	buf.Write(data)
+	buf.WriteString("\n\n")
+	return s.write(buf.String())
+}
+
+func (s *EventSender[Event]) write(text string) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.writeLocked(text)
+}
+
+// writeLocked must be called with mut locked
+func (s *EventSender[Event]) writeLocked(text string) error {
+	if s.closed || s.ctx.Err() != nil {
+		return ErrStreamClosed
+	}
+
+	s.start()
+	if _, err := s.writer.Write([]byte(text)); err != nil {
+		return err
+	}
+	if err := s.ctl.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// start writes the headers of the stream, the status can not be changed after
+func (s *EventSender[Event]) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+
+	header := s.writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	_ = s.ctl.SetWriteDeadline(time.Time{})
+	s.writer.WriteHeader(http.StatusOK)
+}
+
+func (s *EventSender[Event]) isStarted() bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.started
+}
+
+// close prevents the writes after the handler has returned
+func (s *EventSender[Event]) close() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.closed = true
+}
+
+func (s *EventSender[Event]) heartbeat(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.writeHeartbeat()
+		}
+	}
+}
+
+// writeHeartbeat writes a heartbeat comment only if the stream is started, it never commits the status
+func (s *EventSender[Event]) writeHeartbeat() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.started {
+		_ = s.writeLocked(": heartbeat\n\n")
+	}
+}
+
+// SSE registers a GET route streaming server-sent events. The handler returns when the stream is over,
+// the request context is canceled when the client disconnects.
+// An error returned before the first event is written as a json error response,
+// after it is sent as an event named "error" with the ErrorBody as data.
+func SSE[T any, Req any, Event any](
+	r *Router, pattern urls.Path[T],
+	handler func(ctx Context, req Req, events *EventSender[Event]) error,
+	opts ...Option,
+) {
+	r = r.WithOptions(opts...)
+
+	var testPathVal T
+	var testReqVal Req
+	urls.CheckIsSubStruct(testReqVal, testPathVal)
+	r.checkRequestType(testReqVal)
+	checkValidation(testReqVal)
+
+	path := newRoutePath(r, pattern)
+	plan := newBindingPlan(reflect.TypeFor[Req](), path)
+
+	genericHandler := func(ctx Context, req any) (resp any, err error) {
+		return nil, handler(ctx, req.(Req), ctx.state.events.(*EventSender[Event]))
+	}
+	genericHandler = r.wrapHandler(genericHandler)
+
+	heartbeat := r.config.sseHeartbeat
+	if heartbeat == 0 {
+		heartbeat = defaultSSEHeartbeat
+	}
+
+	info := newRouteInfo[Req, Event](RouteKindSSE, http.MethodGet, path, false)
+	r.register(info, func(writer http.ResponseWriter, request *http.Request) {
+		ctx := NewContext(writer, request)
+
+		var req Req
+		if err := plan.bind(request, &req); err != nil {
+			r.writeAPIResp(ctx, nil, err, http.StatusBadRequest)
+			return
+		}
+
+		ctx.state.req = req
+
+		if err := validateRequest(&req); err != nil {
+			r.writeAPIResp(ctx, nil, err, http.StatusUnprocessableEntity)
+			return
+		}
+
+		sender := newEventSender[Event](request.Context(), writer)
+		ctx.state.events = sender
+
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		if heartbeat > 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sender.heartbeat(heartbeat, done)
+			}()
+		}
+
+		_, err := genericHandler(ctx, req)
+		close(done)
+		wg.Wait()
+
+		if err == nil || errors.Is(err, ErrStreamClosed) {
+			sender.close()
+			return
+		}
+		if !sender.isStarted() {
+			sender.close()
+			r.writeAPIResp(ctx, nil, err, http.StatusInternalServerError)
+			return
+		}
+
+		_, errBody := r.resolveError(ctx, err, http.StatusInternalServerError)
+		data, _ := json.Marshal(errBody)
+		_ = sender.writeEvent("", "error", data, 0)
+		sender.close()
+	})
+}
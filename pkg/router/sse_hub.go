@@ -0,0 +1,100 @@
+package router
+
+import (
+	"sync"
+)
+
+const defaultHubBuffer = 16
+
+// Hub broadcasts the events published on a topic to all the subscribers of the topic, in process.
+// A subscriber whose buffer is full misses the event instead of blocking the publisher.
+type Hub[Event any] struct {
+	buffer int
+
+	mut    sync.RWMutex
+	topics map[string]map[*Subscription[Event]]struct{}
+}
+
+// NewHub returns a hub whose subscriptions buffer up to buffer events, 16 if buffer <= 0
+func NewHub[Event any](buffer int) *Hub[Event] {
+	if buffer <= 0 {
+		buffer = defaultHubBuffer
+	}
+	return &Hub[Event]{
+		buffer: buffer,
+		topics: map[string]map[*Subscription[Event]]struct{}{},
+	}
+}
+
+// Subscription receives the events of a topic until it is closed
+type Subscription[Event any] struct {
+	hub    *Hub[Event]
+	topic  string
+	events chan Event
+	once   sync.Once
+}
+
+// Subscribe adds a subscriber to the topic, the subscription must be closed when no longer used
+func (h *Hub[Event]) Subscribe(topic string) *Subscription[Event] {
+	sub := &Subscription[Event]{
+		hub:    h,
+		topic:  topic,
+		events: make(chan Event, h.buffer),
+	}
+
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	subs, ok := h.topics[topic]
+	if !ok {
+		subs = map[*Subscription[Event]]struct{}{}
+		h.topics[topic] = subs
+	}
+	subs[sub] = struct{}{}
+	return sub
+}
+
+// Publish sends the event to the subscribers of the topic, returns the number of subscribers that received it
+func (h *Hub[Event]) Publish(topic string, event Event) int {
+	h.mut.RLock()
+	defer h.mut.RUnlock()
+
+	count := 0
+	for sub := range h.topics[topic] {
+		select {
+		case sub.events <- event:
+			count++
+		default:
+		}
+	}
+	return count
+}
+
+// Subscribers returns the number of subscribers of the topic
+func (h *Hub[Event]) Subscribers(topic string) int {
+	h.mut.RLock()
+	defer h.mut.RUnlock()
+	return len(h.topics[topic])
+}
+
+// Events returns the channel of the events, it is closed when the subscription is closed
+func (s *Subscription[Event]) Events() <-chan Event {
+	return s.events
+}
+
+// Close removes the subscriber from the hub, it can be called multiple times
+func (s *Subscription[Event]) Close() {
+	s.once.Do(func() {
+		h := s.hub
+
+		h.mut.Lock()
+		defer h.mut.Unlock()
+
+		subs := h.topics[s.topic]
+		delete(subs, s)
+		if len(subs) == 0 {
+			delete(h.topics, s.topic)
+		}
+		close(s.events)
+	})
+}
@@ -0,0 +1,243 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"learn-gin/pkg/urls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type jobParams struct {
+	JobID int64 `json:"job_id"`
+}
+
+type jobEventsRequest struct {
+	JobID       int64  `json:"job_id"`
+	LastEventID string `header:"Last-Event-ID"`
+}
+
+type jobProgress struct {
+	Percent int `json:"percent"`
+}
+
+var jobEventsPath = urls.New[jobParams]("/api/jobs/{job_id}/events")
+
+func TestSSE(t *testing.T) {
+	r := NewRouter()
+
+	var inputReq jobEventsRequest
+	SSE(r, jobEventsPath, func(ctx Context, req jobEventsRequest, events *EventSender[jobProgress]) error {
+		inputReq = req
+		switch req.JobID {
+		case 404:
+			return NotFound("job not found")
+		case 500:
+			_ = events.Send(jobProgress{Percent: 10})
+			return errors.New("job failed")
+		}
+
+		_ = events.Comment("start")
+		_ = events.Send(jobProgress{Percent: 10})
+		_ = events.SendNamed("progress", jobProgress{Percent: 50})
+		return events.SendMessage(Message[jobProgress]{
+			ID:    "3",
+			Name:  "done",
+			Data:  jobProgress{Percent: 100},
+			Retry: 2 * time.Second,
+		})
+	})
+
+	serve := func(url string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+		return writer
+	}
+
+	t.Run("events", func(t *testing.T) {
+		writer := serve("/api/jobs/12/events", http.Header{"Last-Event-Id": {"2"}})
+
+		assert.Equal(t, jobEventsRequest{JobID: 12, LastEventID: "2"}, inputReq)
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, true, writer.Flushed)
+		assert.Equal(t, http.Header{
+			"Content-Type":      {"text/event-stream"},
+			"Cache-Control":     {"no-cache"},
+			"X-Accel-Buffering": {"no"},
+		}, writer.Header())
+		assert.Equal(t, ": start\n\n"+
+			"data: {\"percent\":10}\n\n"+
+			"event: progress\ndata: {\"percent\":50}\n\n"+
+			"id: 3\nevent: done\nretry: 2000\ndata: {\"percent\":100}\n\n",
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("error before first event", func(t *testing.T) {
+		writer := serve("/api/jobs/404/events", nil)
+		assert.Equal(t, http.StatusNotFound, writer.Code)
+		assert.Equal(t, `{"error":"job not found","code":"not_found"}`+"\n", writer.Body.String())
+	})
+
+	t.Run("error after first event", func(t *testing.T) {
+		writer := serve("/api/jobs/500/events", nil)
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, "data: {\"percent\":10}\n\n"+
+			"event: error\ndata: {\"error\":\"internal server error\",\"code\":\"internal_error\"}\n\n",
+			writer.Body.String(),
+		)
+	})
+
+	t.Run("invalid param", func(t *testing.T) {
+		writer := serve("/api/jobs/abc/events", nil)
+		assert.Equal(t, http.StatusBadRequest, writer.Code)
+	})
+
+	t.Run("no automatic head", func(t *testing.T) {
+		writer := serve("/api/jobs/12/events", nil)
+		assert.Equal(t, http.StatusOK, writer.Code)
+
+		req := httptest.NewRequest(http.MethodHead, "/api/jobs/12/events", nil)
+		headWriter := httptest.NewRecorder()
+		r.Mux().ServeHTTP(headWriter, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, headWriter.Code)
+	})
+}
+
+func TestSSE_Heartbeat(t *testing.T) {
+	r := NewRouter().WithOptions(WithSSEHeartbeat(5 * time.Millisecond))
+
+	SSE(r, jobEventsPath, func(ctx Context, req jobEventsRequest, events *EventSender[jobProgress]) error {
+		if req.JobID == 404 {
+			time.Sleep(30 * time.Millisecond)
+			return NotFound("job not found")
+		}
+		_ = events.Send(jobProgress{Percent: 10})
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	})
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+		return writer
+	}
+
+	t.Run("after first event", func(t *testing.T) {
+		writer := serve("/api/jobs/12/events")
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, true, strings.HasPrefix(writer.Body.String(), "data: {\"percent\":10}\n\n: heartbeat\n\n"))
+	})
+
+	t.Run("not before first event", func(t *testing.T) {
+		writer := serve("/api/jobs/404/events")
+		assert.Equal(t, http.StatusNotFound, writer.Code)
+		assert.Equal(t, `{"error":"job not found","code":"not_found"}`+"\n", writer.Body.String())
+	})
+}
+
+func TestSSE_Hub_And_Disconnect(t *testing.T) {
+	hub := NewHub[jobProgress](0)
+	r := NewRouter()
+
+	handlerDone := make(chan error, 1)
+	SSE(r, jobEventsPath, func(ctx Context, req jobEventsRequest, events *EventSender[jobProgress]) error {
+		sub := hub.Subscribe("job")
+		defer sub.Close()
+
+		if err := events.Comment("subscribed"); err != nil {
+			return err
+		}
+		for {
+			select {
+			case <-ctx.Context().Done():
+				handlerDone <- ctx.Context().Err()
+				return nil
+			case event := <-sub.Events():
+				if err := events.Send(event); err != nil {
+					return err
+				}
+			}
+		}
+	})
+
+	server := httptest.NewServer(r.Mux())
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	readStream := func() (*bufio.Reader, func()) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/jobs/1/events", nil)
+		assert.Equal(t, nil, err)
+		resp, err := http.DefaultClient.Do(req)
+		assert.Equal(t, nil, err)
+
+		reader := bufio.NewReader(resp.Body)
+		line, _ := reader.ReadString('\n')
+		assert.Equal(t, ": subscribed\n", line)
+		_, _ = reader.ReadString('\n')
+		return reader, func() { _ = resp.Body.Close() }
+	}
+
+	first, closeFirst := readStream()
+	second, closeSecond := readStream()
+	defer closeSecond()
+
+	assert.Equal(t, 2, hub.Publish("job", jobProgress{Percent: 40}))
+	assert.Equal(t, 0, hub.Publish("other", jobProgress{Percent: 40}))
+
+	for _, reader := range []*bufio.Reader{first, second} {
+		line, _ := reader.ReadString('\n')
+		assert.Equal(t, "data: {\"percent\":40}\n", line)
+	}
+
+	closeFirst()
+	cancel()
+
+	select {
+	case err := <-handlerDone:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler not stopped after disconnect")
+	}
+}
+
+func TestHub(t *testing.T) {
+	hub := NewHub[int](2)
+
+	a := hub.Subscribe("numbers")
+	b := hub.Subscribe("numbers")
+	assert.Equal(t, 2, hub.Subscribers("numbers"))
+
+	assert.Equal(t, 2, hub.Publish("numbers", 1))
+	assert.Equal(t, 1, <-a.Events())
+
+	b.Close()
+	b.Close()
+	assert.Equal(t, 1, hub.Subscribers("numbers"))
+
+	assert.Equal(t, 1, <-b.Events())
+	_, ok := <-b.Events()
+	assert.Equal(t, false, ok)
+
+	assert.Equal(t, 1, hub.Publish("numbers", 2))
+	assert.Equal(t, 1, hub.Publish("numbers", 3))
+	assert.Equal(t, 0, hub.Publish("numbers", 4))
+
+	assert.Equal(t, 2, <-a.Events())
+	assert.Equal(t, 3, <-a.Events())
+
+	a.Close()
+	assert.Equal(t, 0, hub.Subscribers("numbers"))
+}
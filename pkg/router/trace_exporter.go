@@ -0,0 +1,53 @@
+package router
+
+import (
+	"slices"
+	"sync"
+)
+
+// InMemoryExporter keeps the exported spans in memory, it is intended for tests
+type InMemoryExporter struct {
+	mut   sync.Mutex
+	spans []SpanData
+}
+
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+func (e *InMemoryExporter) Export(span SpanData) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans in the order they ended
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	return slices.Clone(e.spans)
+}
+
+func (e *InMemoryExporter) Reset() {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	e.spans = nil
+}
+
+// FileExporter writes the spans as json lines
+type FileExporter struct {
+	*jsonLinesWriter
+}
+
+// NewFileExporter returns an exporter appending to the file at the path, the file is created if needed
+func NewFileExporter(path string) (*FileExporter, error) {
+	w, err := openJSONLines(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FileExporter{w}, nil
+}
+
+func (e *FileExporter) Export(span SpanData) {
+	e.write(span)
+}
@@ -0,0 +1,327 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpanContext identifies a span across process boundaries, as defined by W3C trace context
+type SpanContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	Flags      byte
+	TraceState string
+}
+
+const flagSampled byte = 0x01
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&flagSampled != 0
+}
+
+func (sc SpanContext) TraceIDString() string {
+	return hex.EncodeToString(sc.TraceID[:])
+}
+
+func (sc SpanContext) SpanIDString() string {
+	return hex.EncodeToString(sc.SpanID[:])
+}
+
+// Traceparent formats the span context as a traceparent header value
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceIDString(), sc.SpanIDString(), sc.Flags)
+}
+
+// ParseTraceparent parses the traceparent and tracestate header values, returns false if traceparent is invalid
+func ParseTraceparent(traceparent string, tracestate string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return SpanContext{}, false
+	}
+	sc.Flags = flags[0]
+	sc.TraceState = strings.TrimSpace(tracestate)
+
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	return sc, true
+}
+
+// Span is a timed operation of a trace
+type Span interface {
+	SpanContext() SpanContext
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts spans, the new span is a child of the span in the context,
+// or of the remote span extracted from the request headers
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// WithTracer starts a span for each request, named by the route pattern,
+// the incoming traceparent and tracestate headers are used as the parent
+func WithTracer(tracer Tracer) Option {
+	return func(conf *routerConfig) {
+		conf.tracer = tracer
+	}
+}
+
+type (
+	spanKey       struct{}
+	remoteSpanKey struct{}
+	tracerKey     struct{}
+)
+
+// ContextWithSpan returns a copy of the context with the span as the current span
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// ContextWithRemoteSpanContext returns a copy of the context with the parent received from another process
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanKey{}, sc)
+}
+
+// ContextWithTracer returns a copy of the context with the tracer used by StartSpan
+func ContextWithTracer(ctx context.Context, tracer Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, tracer)
+}
+
+// SpanFromContext returns the current span, or a span that records nothing
+func SpanFromContext(ctx context.Context) Span {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	if !ok {
+		return noopSpan{}
+	}
+	return span
+}
+
+// TracerFromContext returns the tracer of the context, nil if none
+func TracerFromContext(ctx context.Context) Tracer {
+	tracer, _ := ctx.Value(tracerKey{}).(Tracer)
+	return tracer
+}
+
+// StartSpan starts a child span with the tracer of the context,
+// the span records nothing if the context has no tracer
+func StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	tracer := TracerFromContext(ctx)
+	if tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return tracer.Start(ctx, name)
+}
+
+func parentSpanContext(ctx context.Context) SpanContext {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteSpanKey{}).(SpanContext)
+	return sc
+}
+
+// InjectTraceContext sets the traceparent and tracestate headers from the current span of the context
+func InjectTraceContext(ctx context.Context, header http.Header) {
+	sc := SpanFromContext(ctx).SpanContext()
+	if !sc.IsValid() {
+		return
+	}
+	header.Set("traceparent", sc.Traceparent())
+	if len(sc.TraceState) > 0 {
+		header.Set("tracestate", sc.TraceState)
+	} else {
+		header.Del("tracestate")
+	}
+}
+
+// Span returns the span of the request, see WithTracer
+func (c Context) Span() Span {
+	return SpanFromContext(c.Context())
+}
+
+// StartSpan starts a child span of the request, the returned function records the error and ends the span
+func (c Context) StartSpan(name string) (end func(err error)) {
+	_, span := StartSpan(c.Context(), name)
+	return func(err error) {
+		span.RecordError(err)
+		span.End()
+	}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SpanContext() SpanContext { return SpanContext{} }
+func (noopSpan) SetAttribute(string, any) {}
+func (noopSpan) RecordError(error)        {}
+func (noopSpan) End()                     {}
+
+// SpanData is a finished span given to the exporters
+type SpanData struct {
+	Name         string         `json:"name"`
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	TraceState   string         `json:"trace_state,omitempty"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+	Error        string         `json:"error,omitempty"`
+}
+
+// SpanExporter receives the finished sampled spans, it must be safe for concurrent use
+type SpanExporter interface {
+	Export(span SpanData)
+}
+
+type tracer struct {
+	exporter SpanExporter
+}
+
+// NewTracer returns a tracer exporting the finished spans to the exporter.
+// A span without parent starts a new sampled trace, the others keep the sampling decision of the parent.
+func NewTracer(exporter SpanExporter) Tracer {
+	return &tracer{exporter: exporter}
+}
+
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent := parentSpanContext(ctx)
+
+	sc := SpanContext{Flags: flagSampled}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Flags = parent.Flags
+		sc.TraceState = parent.TraceState
+	} else {
+		_, _ = rand.Read(sc.TraceID[:])
+	}
+	_, _ = rand.Read(sc.SpanID[:])
+
+	span := &recordingSpan{
+		tracer: t,
+		sc:     sc,
+		data: SpanData{
+			Name:       name,
+			TraceID:    sc.TraceIDString(),
+			SpanID:     sc.SpanIDString(),
+			TraceState: sc.TraceState,
+			Start:      time.Now(),
+		},
+	}
+	if parent.IsValid() {
+		span.data.ParentSpanID = parent.SpanIDString()
+	}
+
+	ctx = ContextWithTracer(ctx, t)
+	return ContextWithSpan(ctx, span), span
+}
+
+type recordingSpan struct {
+	tracer *tracer
+	sc     SpanContext
+
+	mut   sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *recordingSpan) SpanContext() SpanContext {
+	return s.sc
+}
+
+func (s *recordingSpan) SetAttribute(key string, value any) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.data.Attributes == nil {
+		s.data.Attributes = map[string]any{}
+	}
+	s.data.Attributes[key] = value
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.data.Error = err.Error()
+}
+
+func (s *recordingSpan) End() {
+	s.mut.Lock()
+	if s.ended {
+		s.mut.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	// the exporters get their own attributes, the span can still be written after its end
+	data := s.data
+	data.Attributes = maps.Clone(s.data.Attributes)
+	s.mut.Unlock()
+
+	if s.sc.IsSampled() {
+		s.tracer.exporter.Export(data)
+	}
+}
+
+func tracingHandler(tracer Tracer, info RouteInfo, handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		remote, ok := ParseTraceparent(request.Header.Get("traceparent"), request.Header.Get("tracestate"))
+		if ok {
+			ctx = ContextWithRemoteSpanContext(ctx, remote)
+		}
+
+		route := info.Pattern
+		if routeCtx := chi.RouteContext(ctx); routeCtx != nil && len(routeCtx.RoutePatterns) > 0 {
+			route = routeCtx.RoutePattern()
+		}
+
+		ctx, span := tracer.Start(ctx, route)
+		defer span.End()
+
+		span.SetAttribute("http.method", request.Method)
+		span.SetAttribute("http.route", route)
+
+		recorder := newResponseRecorder(writer)
+		handler(recorder, request.WithContext(ctx))
+
+		span.SetAttribute("http.status_code", recorder.Status())
+	}
+}
@@ -0,0 +1,243 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		sc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "vendor=abc")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceIDString())
+		assert.Equal(t, "00f067aa0ba902b7", sc.SpanIDString())
+		assert.Equal(t, true, sc.IsSampled())
+		assert.Equal(t, "vendor=abc", sc.TraceState)
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.Traceparent())
+	})
+
+	t.Run("not sampled", func(t *testing.T) {
+		sc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, false, sc.IsSampled())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, value := range []string{
+			"",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+		} {
+			_, ok := ParseTraceparent(value, "")
+			assert.Equal(t, false, ok, value)
+		}
+	})
+}
+
+func TestWithTracer(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	r := NewRouter().WithOptions(WithTracer(NewTracer(exporter)))
+
+	var handlerSpan SpanContext
+	APIGet(r, userPath, func(ctx Context, req userGetRequest) (userGetResponse, error) {
+		handlerSpan = ctx.Span().SpanContext()
+
+		_, child := StartSpan(ctx.Context(), "load user")
+		child.SetAttribute("user_id", int(req.UserID))
+		child.End()
+
+		if req.UserID == 404 {
+			return userGetResponse{}, NotFound("user not found")
+		}
+		return userGetResponse{}, nil
+	})
+
+	serve := func(url string, header http.Header) *httptest.ResponseRecorder {
+		exporter.Reset()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		writer := httptest.NewRecorder()
+		r.Mux().ServeHTTP(writer, req)
+		return writer
+	}
+
+	t.Run("new trace", func(t *testing.T) {
+		serve("/api/users/123", nil)
+
+		spans := exporter.Spans()
+		assert.Equal(t, 2, len(spans))
+
+		child, route := spans[0], spans[1]
+		assert.Equal(t, "load user", child.Name)
+		assert.Equal(t, map[string]any{"user_id": 123}, child.Attributes)
+		assert.Equal(t, route.SpanID, child.ParentSpanID)
+
+		assert.Equal(t, "/api/users/{user_id}", route.Name)
+		assert.Equal(t, "", route.ParentSpanID)
+		assert.Equal(t, handlerSpan.SpanIDString(), route.SpanID)
+		assert.Equal(t, map[string]any{
+			"http.method":      "GET",
+			"http.route":       "/api/users/{user_id}",
+			"http.status_code": 200,
+		}, route.Attributes)
+	})
+
+	t.Run("remote parent", func(t *testing.T) {
+		serve("/api/users/123", http.Header{
+			"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			"Tracestate":  {"vendor=abc"},
+		})
+
+		spans := exporter.Spans()
+		assert.Equal(t, 2, len(spans))
+
+		route := spans[1]
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", route.TraceID)
+		assert.Equal(t, "00f067aa0ba902b7", route.ParentSpanID)
+		assert.Equal(t, "vendor=abc", route.TraceState)
+	})
+
+	t.Run("remote parent not sampled", func(t *testing.T) {
+		serve("/api/users/123", http.Header{
+			"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		})
+		assert.Equal(t, 0, len(exporter.Spans()))
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", handlerSpan.TraceIDString())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		writer := serve("/api/users/404", nil)
+		assert.Equal(t, http.StatusNotFound, writer.Code)
+
+		route := exporter.Spans()[1]
+		assert.Equal(t, "not_found", route.Attributes["error.code"])
+		assert.Equal(t, 404, route.Attributes["http.status_code"])
+		assert.Equal(t, "", route.Error)
+	})
+}
+
+func TestStartSpan_Without_Tracer(t *testing.T) {
+	ctx, span := StartSpan(context.Background(), "no tracer")
+	span.SetAttribute("key", "value")
+	span.End()
+
+	assert.Equal(t, false, span.SpanContext().IsValid())
+	assert.Equal(t, context.Background(), ctx)
+
+	header := http.Header{}
+	InjectTraceContext(ctx, header)
+	assert.Equal(t, http.Header{}, header)
+}
+
+func TestSpan_Attributes_Copied_On_End(t *testing.T) {
+	exporter := NewInMemoryExporter()
+
+	_, span := NewTracer(exporter).Start(context.Background(), "span")
+	span.SetAttribute("key", "value")
+	span.End()
+	span.SetAttribute("key", "changed")
+	span.SetAttribute("other", "value")
+
+	assert.Equal(t, 1, len(exporter.Spans()))
+	assert.Equal(t, map[string]any{"key": "value"}, exporter.Spans()[0].Attributes)
+}
+
+type tracedTemplate struct {
+	Template
+}
+
+func (t tracedTemplate) RenderContext(ctx context.Context, data any) (template.HTML, error) {
+	_, span := StartSpan(ctx, "render")
+	defer span.End()
+	return t.Render(data)
+}
+
+func TestPage_Traced_Template(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	r := NewRouter().WithOptions(WithTracer(NewTracer(exporter)))
+
+	tmpl := tracedTemplate{Template: loadTestTemplate(`<h1>{{.Username}}</h1>`)}
+	Page(r, userPath, tmpl, func(ctx Context, req userGetRequest) (userView, error) {
+		return userView{UserID: req.UserID, Username: "user01"}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, "<h1>user01</h1>", writer.Body.String())
+
+	spans := exporter.Spans()
+	assert.Equal(t, 2, len(spans))
+	assert.Equal(t, "render", spans[0].Name)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+}
+
+func TestContext_StartSpan(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	r := NewRouter().WithOptions(WithTracer(NewTracer(exporter)))
+
+	HTMLGet(r, userPath, func(ctx Context, req userGetRequest) (template.HTML, error) {
+		end := ctx.StartSpan("views.Render")
+		end(errors.New("render failed"))
+		return "<div>Hello</div>", nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/123", nil)
+	r.Mux().ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.Spans()
+	assert.Equal(t, 2, len(spans))
+	assert.Equal(t, "views.Render", spans[0].Name)
+	assert.Equal(t, "render failed", spans[0].Error)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+
+	exporter, err := NewFileExporter(path)
+	assert.Equal(t, nil, err)
+
+	tracer := NewTracer(exporter)
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+	parent.End()
+	parent.End()
+
+	assert.Equal(t, nil, exporter.Close())
+
+	file, err := os.Open(path)
+	assert.Equal(t, nil, err)
+	defer func() { _ = file.Close() }()
+
+	var spans []SpanData
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var span SpanData
+		assert.Equal(t, nil, json.Unmarshal(scanner.Bytes(), &span))
+		spans = append(spans, span)
+	}
+
+	assert.Equal(t, 2, len(spans))
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, "parent", spans[1].Name)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+	assert.Equal(t, spans[1].TraceID, spans[0].TraceID)
+}
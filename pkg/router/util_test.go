@@ -1,134 +1,193 @@
 package router
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"learn-gin/pkg/null"
+	"learn-gin/pkg/urls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
-type reqBody struct {
-	Name    string `json:"name"`
-	Age     int    `json:"age,omitempty"`
-	Counter uint32 `json:"counter"`
-}
+type testLevel int
 
-type invalidBody struct {
-	Name any `json:"name"`
+func (l testLevel) MarshalText() ([]byte, error) {
+	return []byte([]string{"low", "high"}[l]), nil
 }
 
-func TestAssignParams(t *testing.T) {
-	t.Run("normal", func(t *testing.T) {
-		var req reqBody
-		m := map[string]string{
-			"name":    "user01",
-			"age":     "1234",
-			"counter": "89",
-		}
+func (l *testLevel) UnmarshalText(data []byte) error {
+	switch string(data) {
+	case "low":
+		*l = 0
+	case "high":
+		*l = 1
+	default:
+		return errors.New("invalid level")
+	}
+	return nil
+}
 
-		err := assignParams(&req, []string{"name", "age", "counter"}, func(key string) string {
-			return m[key]
-		})
-		assert.Equal(t, nil, err)
-		assert.Equal(t, reqBody{
-			Name:    "user01",
-			Age:     1234,
-			Counter: 89,
-		}, req)
-	})
+type richParams struct {
+	Name    string          `json:"name"`
+	Active  bool            `json:"active"`
+	Score   float64         `json:"score"`
+	Since   time.Time       `json:"since"`
+	Timeout time.Duration   `json:"timeout"`
+	Level   testLevel       `json:"level"`
+	Limit   *int            `json:"limit"`
+	IDs     []int64         `json:"ids"`
+	Tags    []string        `json:"tags" explode:"false"`
+	Ratio   null.Null[bool] `json:"ratio"`
+	Keys    []string        `json:"keys"`
+}
 
-	t.Run("partial set", func(t *testing.T) {
-		var req reqBody
-		m := map[string]string{
-			"name":    "user01",
-			"age":     "1234",
-			"counter": "89",
+func TestFormPlan_Bind(t *testing.T) {
+	plan := newFormPlan(reflect.TypeFor[richParams]())
+
+	t.Run("rich scalars", func(t *testing.T) {
+		m := url.Values{
+			"name":    {"user01"},
+			"active":  {"true"},
+			"score":   {"1.5"},
+			"since":   {"2024-01-02T03:04:05Z"},
+			"timeout": {"1m30s"},
+			"level":   {"high"},
+			"limit":   {"0"},
+			"ids":     {"1", "2"},
+			"tags":    {"a,b"},
+			"ratio":   {"false"},
 		}
 
-		err := assignParams(&req, []string{"name", "counter"}, func(key string) string {
-			return m[key]
-		})
+		var req richParams
+		err := plan.bindForm(m, nil, &req)
 		assert.Equal(t, nil, err)
-		assert.Equal(t, reqBody{
+
+		limit := 0
+		assert.Equal(t, richParams{
 			Name:    "user01",
-			Counter: 89,
+			Active:  true,
+			Score:   1.5,
+			Since:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			Timeout: 90 * time.Second,
+			Level:   1,
+			Limit:   &limit,
+			IDs:     []int64{1, 2},
+			Tags:    []string{"a", "b"},
+			Ratio:   null.New(false),
 		}, req)
 	})
 
-	t.Run("partial values", func(t *testing.T) {
-		var req reqBody
-		m := map[string]string{
-			"name": "user01",
-		}
-
-		err := assignParams(&req, []string{"name", "age", "counter"}, func(key string) string {
-			return m[key]
-		})
+	t.Run("date only", func(t *testing.T) {
+		var req richParams
+		err := plan.bindForm(url.Values{"since": {"2024-01-02"}}, nil, &req)
 		assert.Equal(t, nil, err)
-		assert.Equal(t, reqBody{
-			Name: "user01",
-		}, req)
+		assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), req.Since)
 	})
 
-	t.Run("not a number", func(t *testing.T) {
-		var req reqBody
-		m := map[string]string{
-			"name": "user01",
-			"age":  "A9",
+	t.Run("parse errors", func(t *testing.T) {
+		cases := map[string]string{
+			"active":  "yes!",
+			"score":   "abc",
+			"since":   "yesterday",
+			"timeout": "10",
+			"level":   "medium",
+			"ids":     "1,x",
+		}
+		for key, value := range cases {
+			var req richParams
+			err := plan.bindForm(url.Values{key: {value}}, nil, &req)
+			var parseErr *ParseError
+			assert.True(t, errors.As(err, &parseErr), key)
 		}
-
-		err := assignParams(&req, []string{"name", "age", "counter"}, func(key string) string {
-			return m[key]
-		})
-		assert.Equal(t, "router: can not parse value 'A9' into field 'Age'", err.Error())
-		assert.Equal(t, reqBody{
-			Name: "user01",
-		}, req)
 	})
 
-	t.Run("not a unsigned number", func(t *testing.T) {
-		var req reqBody
-		m := map[string]string{
-			"name":    "user01",
-			"counter": "A9",
+	t.Run("int overflow", func(t *testing.T) {
+		type request struct {
+			Small int8 `json:"small"`
 		}
-
-		err := assignParams(&req, []string{"name", "age", "counter"}, func(key string) string {
-			return m[key]
-		})
-		assert.Equal(t, "router: can not parse value 'A9' into field 'Counter'", err.Error())
-		assert.Equal(t, reqBody{
-			Name: "user01",
-		}, req)
+		var req request
+		err := newFormPlan(reflect.TypeFor[request]()).bindForm(url.Values{"small": {"300"}}, nil, &req)
+		assert.Equal(t, &ParseError{Field: "Small", Value: "300"}, err)
 	})
 
 	t.Run("invalid type", func(t *testing.T) {
-		var req invalidBody
-		m := map[string]string{
-			"name": "user01",
+		type request struct {
+			Name any `json:"name"`
 		}
-
-		err := assignParams(&req, []string{"name"}, func(key string) string {
-			return m[key]
-		})
+		var req request
+		err := newFormPlan(reflect.TypeFor[request]()).bindForm(url.Values{"name": {"user01"}}, nil, &req)
 		assert.Equal(t, "unrecognized field type 'interface' of field 'Name'", err.Error())
-		assert.Equal(t, invalidBody{}, req)
 	})
 }
 
-type nullReqBody struct {
-	Name null.Null[string] `json:"name"`
+func TestAPIGet_Rich_Params_Round_Trip(t *testing.T) {
+	path := urls.New[richParams]("/api/rich/{name}")
+
+	r := NewRouter()
+
+	var inputReq richParams
+	APIGet(r, path, func(ctx Context, req richParams) (urls.Empty, error) {
+		inputReq = req
+		return urls.Empty{}, nil
+	})
+
+	limit := 0
+	params := richParams{
+		Name:    "a/b c",
+		Active:  true,
+		Score:   0.25,
+		Since:   time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC),
+		Timeout: 90 * time.Second,
+		Level:   1,
+		Limit:   &limit,
+		IDs:     []int64{1, 2, 3},
+		Tags:    []string{"x", "y"},
+		Ratio:   null.New(false),
+		Keys:    []string{"a,b", "c"},
+	}
+
+	url := path.Eval(params)
+	assert.Equal(t, true, strings.Contains(url, "&keys=a%2Cb&keys=c&"))
+	assert.Equal(t, true, strings.Contains(url, "&tags=x%2Cy&"))
+
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, params, inputReq)
 }
 
-func TestAssignParams_With_Null(t *testing.T) {
-	var req nullReqBody
-	m := map[string]string{
-		"name": "user01",
+func TestAPIGet_Path_Param_Round_Trip(t *testing.T) {
+	type request struct {
+		Name string `json:"name"`
 	}
-	err := assignParams(&req, []string{"name"}, func(key string) string {
-		return m[key]
+	path := urls.New[request]("/api/names/{name}")
+
+	r := NewRouter()
+
+	var inputReq request
+	APIGet(r, path, func(ctx Context, req request) (urls.Empty, error) {
+		inputReq = req
+		return urls.Empty{}, nil
 	})
-	assert.Equal(t, nil, err)
-	assert.Equal(t, nullReqBody{
-		Name: null.New("user01"),
-	}, req)
+
+	for _, name := range []string{"user01", "100%", "a%41", "a/b", "a+b", "a b", "%2F"} {
+		t.Run(name, func(t *testing.T) {
+			inputReq = request{}
+			url := path.Eval(request{Name: name})
+
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			writer := httptest.NewRecorder()
+			r.Mux().ServeHTTP(writer, req)
+
+			assert.Equal(t, http.StatusOK, writer.Code)
+			assert.Equal(t, name, inputReq.Name)
+		})
+	}
 }
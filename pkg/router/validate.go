@@ -0,0 +1,525 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"learn-gin/pkg/null"
+	"maps"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Validator can be implemented by request types (and nested structs) for checks
+// that can not be expressed by the validate struct tag.
+// Returning a ValidationErrors adds field errors, returning an *Error overrides the whole response.
+type Validator interface {
+	Validate() error
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Path    string `json:"path"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fmt.Sprintf("%s: %s", fieldErr.Path, fieldErr.Message))
+	}
+	return "router: validation failed: " + strings.Join(messages, "; ")
+}
+
+type validationRule struct {
+	name   string
+	param  string
+	number float64
+	oneOf  []string
+	regex  *regexp.Regexp
+}
+
+type fieldValidation struct {
+	index     int
+	name      string
+	jsonName  string
+	required  bool
+	rules     []validationRule
+	dive      bool
+	elemRules []validationRule
+}
+
+type structValidation struct {
+	fields []fieldValidation
+}
+
+var validationMut sync.RWMutex
+var validationCache = map[reflect.Type]*structValidation{}
+
+func getStructValidation(typ reflect.Type) *structValidation {
+	validationMut.RLock()
+	cached, ok := validationCache[typ]
+	validationMut.RUnlock()
+	if ok {
+		return cached
+	}
+
+	validationMut.Lock()
+	defer validationMut.Unlock()
+	return compileStructValidation(typ)
+}
+
+// compileStructValidation must be called with validationMut locked,
+// the compiled types are only cached if all of them compiled without a panic
+func compileStructValidation(typ reflect.Type) *structValidation {
+	compiled := map[reflect.Type]*structValidation{}
+	result := compileStruct(typ, compiled)
+	maps.Copy(validationCache, compiled)
+	return result
+}
+
+func compileStruct(typ reflect.Type, compiled map[reflect.Type]*structValidation) *structValidation {
+	if cached, ok := validationCache[typ]; ok {
+		return cached
+	}
+	if cached, ok := compiled[typ]; ok {
+		return cached
+	}
+
+	// stored before compiling the fields to support recursive types
+	result := &structValidation{}
+	compiled[typ] = result
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		jsonName := computeJsonName(f.Tag.Get("json"))
+		if len(jsonName) == 0 || jsonName == "-" {
+			jsonName = f.Name
+		}
+
+		rules, elemRules, dive := parseValidateTag(f.Tag.Get("validate"))
+
+		fieldType := unwrapOptionalType(f.Type)
+		required := false
+		var otherRules []validationRule
+		for _, rule := range rules {
+			if rule.name == "required" {
+				required = true
+				continue
+			}
+			checkRuleType(rule, fieldType, f.Name)
+			otherRules = append(otherRules, rule)
+		}
+
+		if dive {
+			if fieldType.Kind() != reflect.Slice && fieldType.Kind() != reflect.Array {
+				panic(fmt.Sprintf("router: can not dive into non slice field '%s'", f.Name))
+			}
+			elemType := unwrapOptionalType(fieldType.Elem())
+			for _, rule := range elemRules {
+				if rule.name != "required" {
+					checkRuleType(rule, elemType, f.Name)
+				}
+			}
+			if isStructType(elemType) {
+				compileStruct(elemType, compiled)
+			}
+		}
+
+		if isStructType(fieldType) {
+			compileStruct(fieldType, compiled)
+		}
+
+		if len(rules) == 0 && !dive && !hasValidation(fieldType, compiled) {
+			continue
+		}
+
+		result.fields = append(result.fields, fieldValidation{
+			index:     i,
+			name:      f.Name,
+			jsonName:  jsonName,
+			required:  required,
+			rules:     otherRules,
+			dive:      dive,
+			elemRules: elemRules,
+		})
+	}
+
+	return result
+}
+
+func parseValidateTag(tag string) (rules []validationRule, elemRules []validationRule, dive bool) {
+	current := &rules
+	for len(tag) > 0 {
+		var part string
+		if strings.HasPrefix(tag, "regex=") {
+			// the regex rule always takes the rest of the tag, it can contain commas
+			part, tag = tag, ""
+		} else {
+			index := strings.Index(tag, ",")
+			if index < 0 {
+				part, tag = tag, ""
+			} else {
+				part, tag = tag[:index], tag[index+1:]
+			}
+		}
+
+		if part == "dive" {
+			if dive {
+				panic("router: dive can only be used once in validate tag")
+			}
+			dive = true
+			current = &elemRules
+			continue
+		}
+		*current = append(*current, newValidationRule(part))
+	}
+	return rules, elemRules, dive
+}
+
+func newValidationRule(part string) validationRule {
+	name, param, _ := strings.Cut(part, "=")
+	rule := validationRule{
+		name:  name,
+		param: param,
+	}
+
+	switch name {
+	case "required", "email":
+		if len(param) > 0 {
+			panic(fmt.Sprintf("router: validation rule '%s' does not accept a param", name))
+		}
+
+	case "min", "max", "len":
+		num, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("router: invalid param '%s' of validation rule '%s'", param, name))
+		}
+		rule.number = num
+
+	case "oneof":
+		rule.oneOf = strings.Fields(param)
+		if len(rule.oneOf) == 0 {
+			panic("router: validation rule 'oneof' requires at least one value")
+		}
+
+	case "regex":
+		rule.regex = regexp.MustCompile(param)
+
+	default:
+		panic(fmt.Sprintf("router: unknown validation rule '%s'", name))
+	}
+
+	return rule
+}
+
+func checkRuleType(rule validationRule, typ reflect.Type, fieldName string) {
+	kind := typ.Kind()
+
+	var ok bool
+	switch rule.name {
+	case "min", "max":
+		ok = isNumberKind(kind) || isLengthKind(kind)
+	case "len":
+		ok = isLengthKind(kind)
+	case "oneof":
+		ok = kind == reflect.String || isIntegerKind(kind)
+	case "regex", "email":
+		ok = kind == reflect.String
+	}
+
+	if !ok {
+		panic(fmt.Sprintf(
+			"router: validation rule '%s' can not be used with field '%s' of type '%s'",
+			rule.name, fieldName, typ,
+		))
+	}
+}
+
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	default:
+		return false
+	}
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return isIntegerKind(kind) || kind == reflect.Float32 || kind == reflect.Float64
+}
+
+func isLengthKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	default:
+		return false
+	}
+}
+
+func isStructType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct
+}
+
+var validatorType = reflect.TypeFor[Validator]()
+
+// hasValidation must be called with validationMut locked, after the struct type was compiled
+func hasValidation(typ reflect.Type, compiled map[reflect.Type]*structValidation) bool {
+	if !isStructType(typ) {
+		return false
+	}
+	if typ.Implements(validatorType) || reflect.PointerTo(typ).Implements(validatorType) {
+		return true
+	}
+	if cached, ok := validationCache[typ]; ok {
+		return len(cached.fields) > 0
+	}
+	return len(compiled[typ].fields) > 0
+}
+
+// unwrapOptionalType returns the inner type of pointers and null.Null
+func unwrapOptionalType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Pointer {
+		return unwrapOptionalType(typ.Elem())
+	}
+	_, dataVal, ok := null.IsNullType(reflect.Zero(typ))
+	if ok {
+		return dataVal.Type()
+	}
+	return typ
+}
+
+// unwrapOptional returns the inner value of pointers and null.Null, present is false for nil and null
+func unwrapOptional(val reflect.Value) (inner reflect.Value, present bool, optional bool) {
+	if val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return val, false, true
+		}
+		inner, present, _ = unwrapOptional(val.Elem())
+		return inner, present, true
+	}
+
+	validVal, dataVal, ok := null.IsNullType(val)
+	if ok {
+		if !validVal.Bool() {
+			return val, false, true
+		}
+		return dataVal, true, true
+	}
+	return val, true, false
+}
+
+func validateRequest(req any) error {
+	var errs []FieldError
+	if err := validateStruct(reflect.ValueOf(req).Elem(), "", "", &errs); err != nil {
+		return err
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return Validation("request validation failed", nil).WithFields(errs)
+}
+
+func validateStruct(val reflect.Value, field string, path string, errs *[]FieldError) error {
+	for _, fv := range getStructValidation(val.Type()).fields {
+		err := validateField(
+			val.Field(fv.index), fv.required, fv.rules, fv.dive, fv.elemRules,
+			joinFieldName(field, fv.name), joinFieldName(path, fv.jsonName), errs,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	var validator Validator
+	if val.CanAddr() {
+		validator, _ = val.Addr().Interface().(Validator)
+	} else {
+		validator, _ = val.Interface().(Validator)
+	}
+	if validator == nil {
+		return nil
+	}
+
+	err := validator.Validate()
+	if err == nil {
+		return nil
+	}
+
+	var routerErr *Error
+	if errors.As(err, &routerErr) {
+		return routerErr
+	}
+
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		for _, fieldErr := range validationErrs {
+			fieldErr.Field = joinFieldName(field, fieldErr.Field)
+			fieldErr.Path = joinFieldName(path, fieldErr.Path)
+			*errs = append(*errs, fieldErr)
+		}
+		return nil
+	}
+
+	*errs = append(*errs, FieldError{
+		Field:   field,
+		Path:    path,
+		Rule:    "validate",
+		Message: err.Error(),
+	})
+	return nil
+}
+
+func validateField(
+	val reflect.Value, required bool, rules []validationRule,
+	dive bool, elemRules []validationRule,
+	field string, path string, errs *[]FieldError,
+) error {
+	// only nil pointers and null values are absent, the zero values of the other fields are checked by the rules
+	inner, present, optional := unwrapOptional(val)
+	isEmpty := !optional && inner.IsZero()
+	if !present || (isEmpty && required) {
+		if required {
+			*errs = append(*errs, FieldError{
+				Field:   field,
+				Path:    path,
+				Rule:    "required",
+				Message: "is required",
+			})
+		}
+		return nil
+	}
+
+	for _, rule := range rules {
+		message, ok := checkRule(rule, inner)
+		if !ok {
+			*errs = append(*errs, FieldError{
+				Field:   field,
+				Path:    path,
+				Rule:    rule.name,
+				Message: message,
+			})
+			return nil
+		}
+	}
+
+	if dive {
+		elemRequired := false
+		var otherElemRules []validationRule
+		for _, rule := range elemRules {
+			if rule.name == "required" {
+				elemRequired = true
+				continue
+			}
+			otherElemRules = append(otherElemRules, rule)
+		}
+
+		for i := 0; i < inner.Len(); i++ {
+			err := validateField(
+				inner.Index(i), elemRequired, otherElemRules, false, nil,
+				fmt.Sprintf("%s[%d]", field, i), fmt.Sprintf("%s[%d]", path, i), errs,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if inner.Kind() == reflect.Struct {
+		return validateStruct(inner, field, path, errs)
+	}
+	return nil
+}
+
+func checkRule(rule validationRule, val reflect.Value) (string, bool) {
+	switch rule.name {
+	case "min":
+		if isLengthKind(val.Kind()) {
+			return fmt.Sprintf("length must be at least %s", rule.param), float64(valueLength(val)) >= rule.number
+		}
+		return fmt.Sprintf("must be at least %s", rule.param), numberValue(val) >= rule.number
+
+	case "max":
+		if isLengthKind(val.Kind()) {
+			return fmt.Sprintf("length must be at most %s", rule.param), float64(valueLength(val)) <= rule.number
+		}
+		return fmt.Sprintf("must be at most %s", rule.param), numberValue(val) <= rule.number
+
+	case "len":
+		return fmt.Sprintf("length must be %s", rule.param), float64(valueLength(val)) == rule.number
+
+	case "oneof":
+		str := fmt.Sprint(val.Interface())
+		for _, option := range rule.oneOf {
+			if option == str {
+				return "", true
+			}
+		}
+		return fmt.Sprintf("must be one of [%s]", strings.Join(rule.oneOf, " ")), false
+
+	case "regex":
+		return fmt.Sprintf("must match pattern '%s'", rule.param), rule.regex.MatchString(val.String())
+
+	case "email":
+		addr, err := mail.ParseAddress(val.String())
+		return "must be a valid email address", err == nil && addr.Address == val.String()
+
+	default:
+		return "", true
+	}
+}
+
+func valueLength(val reflect.Value) int {
+	if val.Kind() == reflect.String {
+		return utf8.RuneCountInString(val.String())
+	}
+	return val.Len()
+}
+
+func numberValue(val reflect.Value) float64 {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(val.Uint())
+	default:
+		return val.Float()
+	}
+}
+
+func joinFieldName(prefix string, name string) string {
+	if len(prefix) == 0 {
+		return name
+	}
+	if len(name) == 0 {
+		return prefix
+	}
+	return prefix + "." + name
+}
+
+func typeHasValidation(typ reflect.Type) bool {
+	validationMut.Lock()
+	defer validationMut.Unlock()
+
+	compileStructValidation(typ)
+	return hasValidation(typ, nil)
+}
+
+// checkValidation panics at registration time if the validate tags of the request type are invalid
+func checkValidation(req any) {
+	getStructValidation(reflect.TypeOf(req))
+}
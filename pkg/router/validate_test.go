@@ -0,0 +1,221 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"learn-gin/pkg/null"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type validateAddress struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `json:"zip" validate:"len=5,regex=^[0-9]+$"`
+}
+
+type validateTagRequest struct {
+	Name     string            `json:"name" validate:"required,min=3,max=10"`
+	Age      int               `json:"age" validate:"min=18,max=130"`
+	Email    string            `json:"email" validate:"email"`
+	Role     string            `json:"role" validate:"oneof=admin member"`
+	Nickname null.Null[string] `json:"nickname" validate:"min=2"`
+	Score    null.Null[int]    `json:"score" validate:"required"`
+	Tags     []string          `json:"tags" validate:"max=3,dive,required,max=5"`
+	Address  validateAddress   `json:"address"`
+	Others   []validateAddress `json:"others" validate:"dive"`
+}
+
+func TestValidateRequest(t *testing.T) {
+	validReq := func() validateTagRequest {
+		return validateTagRequest{
+			Name:    "user01",
+			Age:     20,
+			Email:   "user@example.com",
+			Role:    "admin",
+			Score:   null.New(0),
+			Address: validateAddress{City: "Hanoi", Zip: "10000"},
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		req := validReq()
+		req.Nickname = null.New("nick")
+		req.Tags = []string{"a", "b"}
+		req.Others = []validateAddress{{City: "Hue", Zip: "20000"}}
+
+		assert.Equal(t, nil, validateRequest(&req))
+	})
+
+	t.Run("required", func(t *testing.T) {
+		var req validateTagRequest
+		err := validateRequest(&req)
+
+		var routerErr *Error
+		assert.Equal(t, true, errors.As(err, &routerErr))
+		assert.Equal(t, http.StatusUnprocessableEntity, routerErr.Status)
+		assert.Equal(t, []FieldError{
+			{Field: "Name", Path: "name", Rule: "required", Message: "is required"},
+			{Field: "Age", Path: "age", Rule: "min", Message: "must be at least 18"},
+			{Field: "Email", Path: "email", Rule: "email", Message: "must be a valid email address"},
+			{Field: "Role", Path: "role", Rule: "oneof", Message: "must be one of [admin member]"},
+			{Field: "Score", Path: "score", Rule: "required", Message: "is required"},
+			{Field: "Address.City", Path: "address.city", Rule: "required", Message: "is required"},
+			{Field: "Address.Zip", Path: "address.zip", Rule: "len", Message: "length must be 5"},
+		}, routerErr.Fields)
+	})
+
+	t.Run("zero values", func(t *testing.T) {
+		req := validReq()
+		req.Age = 0
+		req.Role = ""
+		req.Nickname = null.Null[string]{}
+
+		var routerErr *Error
+		assert.Equal(t, true, errors.As(validateRequest(&req), &routerErr))
+		assert.Equal(t, []FieldError{
+			{Field: "Age", Path: "age", Rule: "min", Message: "must be at least 18"},
+			{Field: "Role", Path: "role", Rule: "oneof", Message: "must be one of [admin member]"},
+		}, routerErr.Fields)
+	})
+
+	t.Run("rules", func(t *testing.T) {
+		req := validReq()
+		req.Name = "ab"
+		req.Age = 10
+		req.Email = "not an email"
+		req.Role = "guest"
+		req.Nickname = null.New("")
+		req.Tags = []string{"a", "", "too-long"}
+		req.Address.Zip = "12a45"
+		req.Others = []validateAddress{{City: "Hue", Zip: "20000"}, {Zip: "30000"}}
+
+		err := validateRequest(&req)
+
+		var routerErr *Error
+		assert.Equal(t, true, errors.As(err, &routerErr))
+		assert.Equal(t, []FieldError{
+			{Field: "Name", Path: "name", Rule: "min", Message: "length must be at least 3"},
+			{Field: "Age", Path: "age", Rule: "min", Message: "must be at least 18"},
+			{Field: "Email", Path: "email", Rule: "email", Message: "must be a valid email address"},
+			{Field: "Role", Path: "role", Rule: "oneof", Message: "must be one of [admin member]"},
+			{Field: "Nickname", Path: "nickname", Rule: "min", Message: "length must be at least 2"},
+			{Field: "Tags[1]", Path: "tags[1]", Rule: "required", Message: "is required"},
+			{Field: "Tags[2]", Path: "tags[2]", Rule: "max", Message: "length must be at most 5"},
+			{Field: "Address.Zip", Path: "address.zip", Rule: "regex", Message: "must match pattern '^[0-9]+$'"},
+			{Field: "Others[1].City", Path: "others[1].city", Rule: "required", Message: "is required"},
+		}, routerErr.Fields)
+	})
+}
+
+type validateMethodRequest struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+func (r validateMethodRequest) Validate() error {
+	if r.From == -1 {
+		return Forbidden("negative range")
+	}
+	if r.From > r.To {
+		return ValidationErrors{
+			{Field: "From", Path: "from", Rule: "range", Message: "must be less than or equal to 'to'"},
+		}
+	}
+	return nil
+}
+
+func TestValidateRequest_Validate_Method(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		req := validateMethodRequest{From: 1, To: 2}
+		assert.Equal(t, nil, validateRequest(&req))
+	})
+
+	t.Run("field errors", func(t *testing.T) {
+		req := validateMethodRequest{From: 3, To: 2}
+
+		var routerErr *Error
+		assert.Equal(t, true, errors.As(validateRequest(&req), &routerErr))
+		assert.Equal(t, []FieldError{
+			{Field: "From", Path: "from", Rule: "range", Message: "must be less than or equal to 'to'"},
+		}, routerErr.Fields)
+	})
+
+	t.Run("router error", func(t *testing.T) {
+		req := validateMethodRequest{From: -1}
+		assert.Equal(t, Forbidden("negative range"), validateRequest(&req))
+	})
+}
+
+func TestCheckValidation_Invalid_Tags(t *testing.T) {
+	t.Run("unknown rule", func(t *testing.T) {
+		type request struct {
+			Name string `json:"name" validate:"unknown"`
+		}
+		assert.PanicsWithValue(t, "router: unknown validation rule 'unknown'", func() {
+			checkValidation(request{})
+		})
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		type request struct {
+			Age int `json:"age" validate:"email"`
+		}
+		assert.PanicsWithValue(t,
+			"router: validation rule 'email' can not be used with field 'Age' of type 'int'",
+			func() {
+				checkValidation(request{})
+			},
+		)
+	})
+
+	t.Run("panics again on the second registration", func(t *testing.T) {
+		type inner struct {
+			Name string `json:"name" validate:"max=5"`
+		}
+		type request struct {
+			Inner inner `json:"inner"`
+			Age   int   `json:"age" validate:"email"`
+		}
+		for range 2 {
+			assert.PanicsWithValue(t,
+				"router: validation rule 'email' can not be used with field 'Age' of type 'int'",
+				func() {
+					checkValidation(request{})
+				},
+			)
+		}
+	})
+}
+
+type userValidatedRequest struct {
+	UserID userID `json:"user_id"`
+	Search string `json:"search"`
+	Age    int    `json:"age"`
+	Title  string `json:"title" validate:"max=5"`
+	Body   string `json:"body" validate:"required"`
+}
+
+func TestAPIPost_With_Validation_Error(t *testing.T) {
+	r := NewRouter()
+
+	called := false
+	APIPost(r, userPath, func(ctx Context, req userValidatedRequest) (userGetResponse, error) {
+		called = true
+		return userGetResponse{}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", bytes.NewBufferString(`{"title":"too long title"}`))
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+
+	assert.Equal(t, false, called)
+	assert.Equal(t, http.StatusUnprocessableEntity, writer.Code)
+	assert.Equal(t,
+		`{"error":"request validation failed","code":"validation_failed","fields":[`+
+			`{"field":"Title","path":"title","rule":"max","message":"length must be at most 5"},`+
+			`{"field":"Body","path":"body","rule":"required","message":"is required"}]}`+"\n",
+		writer.Body.String(),
+	)
+}
@@ -0,0 +1,117 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const minKeySize = 32
+
+type codecKey struct {
+	signKey []byte
+	aead    cipher.AEAD
+}
+
+// codec signs the cookie values with HMAC-SHA256, or encrypts them with AES-GCM.
+// The values are encoded with the first key and decoded with any of the keys, so that the keys can be rotated.
+type codec struct {
+	keys    []codecKey
+	encrypt bool
+}
+
+func newCodec(secrets [][]byte, encrypt bool) *codec {
+	if len(secrets) == 0 {
+		panic("session: at least one key is required")
+	}
+
+	c := &codec{encrypt: encrypt}
+	for i, secret := range secrets {
+		if len(secret) < minKeySize {
+			panic(fmt.Sprintf("session: key %d must have at least %d bytes", i, minKeySize))
+		}
+
+		// the keys of the signing and of the encryption are derived from the same secret
+		block, err := aes.NewCipher(deriveKey(secret, "session encryption"))
+		if err != nil {
+			panic(err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			panic(err)
+		}
+		c.keys = append(c.keys, codecKey{
+			signKey: deriveKey(secret, "session signing"),
+			aead:    aead,
+		})
+	}
+	return c
+}
+
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// encode returns the cookie value of the data, the name of the cookie is authenticated with the data
+func (c *codec) encode(name string, data []byte) string {
+	key := c.keys[0]
+	if c.encrypt {
+		nonce := make([]byte, key.aead.NonceSize())
+		_, _ = rand.Read(nonce)
+		sealed := key.aead.Seal(nonce, nonce, data, []byte(name))
+		return base64.RawURLEncoding.EncodeToString(sealed)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(sign(key.signKey, name, payload))
+}
+
+// decode returns the data of the cookie value, false if the value was not encoded by one of the keys
+func (c *codec) decode(name string, value string) ([]byte, bool) {
+	if c.encrypt {
+		sealed, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			return nil, false
+		}
+		for _, key := range c.keys {
+			nonceSize := key.aead.NonceSize()
+			if len(sealed) < nonceSize {
+				return nil, false
+			}
+			data, err := key.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(name))
+			if err == nil {
+				return data, true
+			}
+		}
+		return nil, false
+	}
+
+	payload, encodedMAC, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil {
+		return nil, false
+	}
+	for _, key := range c.keys {
+		if hmac.Equal(mac, sign(key.signKey, name, payload)) {
+			data, err := base64.RawURLEncoding.DecodeString(payload)
+			return data, err == nil
+		}
+	}
+	return nil, false
+}
+
+func sign(key []byte, name string, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name + "|" + payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,444 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learn-gin/pkg/router"
+	"net/http"
+	"time"
+)
+
+const (
+	idSize = 32
+
+	defaultIdleTimeout     = 30 * time.Minute
+	defaultAbsoluteTimeout = 24 * time.Hour
+
+	// touchInterval limits the writes of the access time of the sessions used by every request
+	touchInterval = time.Minute
+
+	maxCookieSize = 4096
+)
+
+var (
+	ErrNoSession = errors.New("session: the request has no session, the middleware of the manager is missing")
+	ErrCommitted = errors.New("session: the response headers are already written")
+)
+
+// Options configures the sessions of a Manager
+type Options struct {
+	// Keys sign and encrypt the cookies, each key must have at least 32 random bytes.
+	// The first key encodes the cookies, the others are only used to decode them, so that the keys can be rotated.
+	Keys [][]byte
+	// Encrypt encrypts the cookies with AES-GCM, otherwise the cookies are only signed with HMAC-SHA256
+	Encrypt bool
+
+	// Store keeps the data of the sessions on the server side, the cookie then only holds the session id.
+	// The data is kept in the cookie if nil, which is limited to 4KB.
+	Store Store
+
+	// CookieName is "session" if empty
+	CookieName   string
+	CookiePath   string
+	CookieDomain string
+	Secure       bool
+	// SameSite is http.SameSiteLaxMode if zero
+	SameSite http.SameSite
+
+	// IdleTimeout expires the sessions not used for the duration, 30 minutes if zero, disabled if negative
+	IdleTimeout time.Duration
+	// AbsoluteTimeout expires the sessions after the duration since their creation, 24 hours if zero, disabled if negative
+	AbsoluteTimeout time.Duration
+
+	// ErrorHandler writes the response when the store fails to load a session, a 500 error by default
+	ErrorHandler func(writer http.ResponseWriter, request *http.Request, err error)
+}
+
+// Manager loads the sessions of the requests with its Middleware,
+// the sessions are then accessed by the handlers with Get and Save.
+// It can be used as the router.CSRFStore of the synchronizer token pattern.
+type Manager struct {
+	opts  Options
+	codec *codec
+	now   func() time.Time
+}
+
+func New(opts Options) *Manager {
+	if len(opts.CookieName) == 0 {
+		opts.CookieName = "session"
+	}
+	if len(opts.CookiePath) == 0 {
+		opts.CookiePath = "/"
+	}
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+	if opts.IdleTimeout == 0 {
+		opts.IdleTimeout = defaultIdleTimeout
+	}
+	if opts.AbsoluteTimeout == 0 {
+		opts.AbsoluteTimeout = defaultAbsoluteTimeout
+	}
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = func(writer http.ResponseWriter, request *http.Request, err error) {
+			http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	return &Manager{
+		opts:  opts,
+		codec: newCodec(opts.Keys, opts.Encrypt),
+		now:   time.Now,
+	}
+}
+
+type stateKey struct{}
+
+// state is the session of a request
+type state struct {
+	m       *Manager
+	ctx     context.Context
+	record  Record
+	stored  bool // the record is in the cookie or in the store
+	changed bool // the cookie must be written
+	cleared bool // the cookie must be deleted
+	written bool // the response headers are written
+}
+
+// Middleware loads the session of the request and writes its cookie before the response headers,
+// it is a router.HTTPMiddleware
+func (m *Manager) Middleware(info router.RouteInfo, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		s, err := m.load(request)
+		if err != nil {
+			m.opts.ErrorHandler(writer, request, err)
+			return
+		}
+
+		request = request.WithContext(context.WithValue(request.Context(), stateKey{}, s))
+		w := &sessionWriter{ResponseWriter: writer, state: s}
+		next.ServeHTTP(w, request)
+		w.writeCookie()
+	})
+}
+
+func (m *Manager) load(request *http.Request) (*state, error) {
+	now := m.now()
+	s := &state{m: m, ctx: request.Context()}
+
+	record, ok, err := m.loadRecord(request)
+	if err != nil {
+		return nil, err
+	}
+	if ok && m.expired(record, now) {
+		if m.opts.Store != nil {
+			if err := m.opts.Store.Delete(s.ctx, record.ID); err != nil {
+				return nil, err
+			}
+		}
+		ok = false
+		s.cleared = true
+	}
+	if !ok {
+		s.record = m.newRecord(now)
+		return s, nil
+	}
+
+	s.record = record
+	s.stored = true
+	if now.Sub(record.AccessedAt) >= touchInterval {
+		s.record.AccessedAt = now
+		if err := s.persist(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// loadRecord returns the record of the cookie of the request, false if the cookie is missing or invalid
+func (m *Manager) loadRecord(request *http.Request) (Record, bool, error) {
+	cookie, err := request.Cookie(m.opts.CookieName)
+	if err != nil {
+		return Record{}, false, nil
+	}
+	data, ok := m.codec.decode(m.opts.CookieName, cookie.Value)
+	if !ok {
+		return Record{}, false, nil
+	}
+
+	if m.opts.Store != nil {
+		return m.opts.Store.Load(request.Context(), string(data))
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return Record{}, false, nil
+	}
+	return record, true, nil
+}
+
+func (m *Manager) newRecord(now time.Time) Record {
+	id := make([]byte, idSize)
+	_, _ = rand.Read(id)
+	return Record{
+		ID:         hex.EncodeToString(id),
+		CreatedAt:  now,
+		AccessedAt: now,
+	}
+}
+
+func (m *Manager) expired(record Record, now time.Time) bool {
+	if m.opts.IdleTimeout > 0 && now.Sub(record.AccessedAt) >= m.opts.IdleTimeout {
+		return true
+	}
+	return m.opts.AbsoluteTimeout > 0 && now.Sub(record.CreatedAt) >= m.opts.AbsoluteTimeout
+}
+
+// expiresAt returns the time after which the record is expired, the zero time if it never expires
+func (m *Manager) expiresAt(record Record) time.Time {
+	var expiresAt time.Time
+	if m.opts.IdleTimeout > 0 {
+		expiresAt = record.AccessedAt.Add(m.opts.IdleTimeout)
+	}
+	if m.opts.AbsoluteTimeout > 0 {
+		absolute := record.CreatedAt.Add(m.opts.AbsoluteTimeout)
+		if expiresAt.IsZero() || absolute.Before(expiresAt) {
+			expiresAt = absolute
+		}
+	}
+	return expiresAt
+}
+
+// persist saves the record in the store, or marks the cookie holding the record to be written
+func (s *state) persist() error {
+	if s.written {
+		return ErrCommitted
+	}
+
+	m := s.m
+	if m.opts.Store == nil {
+		if _, err := s.cookieValue(); err != nil {
+			return err
+		}
+		s.changed = true
+		s.stored = true
+		return nil
+	}
+
+	expiresAt := m.expiresAt(s.record)
+	if expiresAt.IsZero() {
+		expiresAt = m.now().Add(100 * 365 * 24 * time.Hour)
+	}
+	if err := m.opts.Store.Save(s.ctx, s.record, expiresAt); err != nil {
+		return err
+	}
+	if !s.stored {
+		s.changed = true
+	}
+	s.stored = true
+	return nil
+}
+
+func (s *state) cookieValue() (string, error) {
+	m := s.m
+
+	data := []byte(s.record.ID)
+	if m.opts.Store == nil {
+		var err error
+		data, err = json.Marshal(s.record)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	value := m.codec.encode(m.opts.CookieName, data)
+	if len(value) > maxCookieSize {
+		return "", fmt.Errorf("session: the cookie of %d bytes exceeds the limit of %d bytes", len(value), maxCookieSize)
+	}
+	return value, nil
+}
+
+func (s *state) cookie() *http.Cookie {
+	m := s.m
+	cookie := &http.Cookie{
+		Name:     m.opts.CookieName,
+		Path:     m.opts.CookiePath,
+		Domain:   m.opts.CookieDomain,
+		Secure:   m.opts.Secure,
+		HttpOnly: true,
+		SameSite: m.opts.SameSite,
+	}
+
+	if !s.changed {
+		cookie.MaxAge = -1
+		return cookie
+	}
+
+	cookie.Value, _ = s.cookieValue()
+	if m.opts.AbsoluteTimeout > 0 {
+		cookie.Expires = s.record.CreatedAt.Add(m.opts.AbsoluteTimeout)
+	}
+	return cookie
+}
+
+// sessionWriter writes the cookie of the session before the response headers
+type sessionWriter struct {
+	http.ResponseWriter
+	state *state
+}
+
+func (w *sessionWriter) writeCookie() {
+	s := w.state
+	if s.written {
+		return
+	}
+	s.written = true
+	if s.changed || s.cleared {
+		http.SetCookie(w.ResponseWriter, s.cookie())
+	}
+}
+
+func (w *sessionWriter) WriteHeader(status int) {
+	w.writeCookie()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *sessionWriter) Write(data []byte) (int, error) {
+	w.writeCookie()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *sessionWriter) Flush() {
+	w.writeCookie()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap is used by http.ResponseController to access the underlying writer
+func (w *sessionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func stateOf(ctx context.Context) (*state, bool) {
+	s, ok := ctx.Value(stateKey{}).(*state)
+	return s, ok
+}
+
+// Get returns the data of the session of the request, false if the session has no data of the type
+func Get[T any](ctx router.Context) (T, bool) {
+	var value T
+	s, ok := stateOf(ctx.Context())
+	if !ok || len(s.record.Data) == 0 {
+		return value, false
+	}
+	if err := json.Unmarshal(s.record.Data, &value); err != nil {
+		return value, false
+	}
+	return value, true
+}
+
+// Save replaces the data of the session, the session is created if the request has none.
+// It must be called before the response is written.
+func Save[T any](ctx router.Context, value T) error {
+	s, ok := stateOf(ctx.Context())
+	if !ok {
+		return ErrNoSession
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	previous := s.record.Data
+	s.record.Data = data
+	if err := s.persist(); err != nil {
+		s.record.Data = previous
+		return err
+	}
+	return nil
+}
+
+// ID returns the id of the session, it changes when the session is regenerated
+func ID(ctx router.Context) string {
+	s, ok := stateOf(ctx.Context())
+	if !ok {
+		return ""
+	}
+	return s.record.ID
+}
+
+// Regenerate moves the session to a new id, it must be called when the privilege of the user changes,
+// e.g. at the login, so that a session id obtained before can not be used (session fixation)
+func Regenerate(ctx router.Context) error {
+	s, ok := stateOf(ctx.Context())
+	if !ok {
+		return ErrNoSession
+	}
+	if s.written {
+		return ErrCommitted
+	}
+
+	m := s.m
+	if m.opts.Store != nil && s.stored {
+		if err := m.opts.Store.Delete(s.ctx, s.record.ID); err != nil {
+			return err
+		}
+	}
+
+	record := m.newRecord(m.now())
+	record.Data = s.record.Data
+	record.CSRFToken = s.record.CSRFToken
+	s.record = record
+	s.stored = false
+	s.changed = false
+	return s.persist()
+}
+
+// Destroy deletes the session and its cookie, e.g. at the logout
+func Destroy(ctx router.Context) error {
+	s, ok := stateOf(ctx.Context())
+	if !ok {
+		return ErrNoSession
+	}
+	if s.written {
+		return ErrCommitted
+	}
+
+	m := s.m
+	if m.opts.Store != nil && s.stored {
+		if err := m.opts.Store.Delete(s.ctx, s.record.ID); err != nil {
+			return err
+		}
+	}
+
+	s.record = m.newRecord(m.now())
+	s.stored = false
+	s.changed = false
+	s.cleared = true
+	return nil
+}
+
+// LoadToken returns the csrf token of the session, see router.CSRFStore
+func (m *Manager) LoadToken(request *http.Request) (string, bool) {
+	s, ok := stateOf(request.Context())
+	if !ok || len(s.record.CSRFToken) == 0 {
+		return "", false
+	}
+	return s.record.CSRFToken, true
+}
+
+// SaveToken keeps the csrf token in the session, see router.CSRFStore
+func (m *Manager) SaveToken(writer http.ResponseWriter, request *http.Request, token string) error {
+	s, ok := stateOf(request.Context())
+	if !ok {
+		return ErrNoSession
+	}
+	s.record.CSRFToken = token
+	return s.persist()
+}
@@ -0,0 +1,354 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"html/template"
+	"io"
+	"learn-gin/pkg/router"
+	"learn-gin/pkg/urls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	loginPath   = urls.NewEmpty("/login")
+	profilePath = urls.NewEmpty("/profile")
+	logoutPath  = urls.NewEmpty("/logout")
+)
+
+var (
+	testKey1 = bytes.Repeat([]byte("k"), 32)
+	testKey2 = bytes.Repeat([]byte("n"), 32)
+)
+
+type userSession struct {
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+type loginRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+type emptyRequest struct{}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newTestManager(opts Options) (*Manager, *testClock) {
+	m := New(opts)
+	clock := &testClock{now: time.Now()}
+	m.now = clock.Now
+	return m, clock
+}
+
+func newTestRouter(m *Manager) *router.Router {
+	r := router.NewRouter().WithHTTPMiddlewares(m.Middleware)
+
+	router.HTMLPost(r, loginPath, func(ctx router.Context, req loginRequest) (template.HTML, error) {
+		if err := Regenerate(ctx); err != nil {
+			return "", err
+		}
+		if err := Save(ctx, userSession{UserID: req.UserID, Role: "admin"}); err != nil {
+			return "", err
+		}
+		return "ok", nil
+	})
+	router.HTMLGet(r, profilePath, func(ctx router.Context, req emptyRequest) (template.HTML, error) {
+		user, ok := Get[userSession](ctx)
+		if !ok {
+			return "anonymous", nil
+		}
+		return template.HTML(user.Role), nil
+	})
+	router.HTMLPost(r, logoutPath, func(ctx router.Context, req emptyRequest) (template.HTML, error) {
+		return "bye", Destroy(ctx)
+	})
+	return r
+}
+
+func serve(r *router.Router, method string, path string, body string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	writer := httptest.NewRecorder()
+	r.Mux().ServeHTTP(writer, req)
+	return writer
+}
+
+func login(t *testing.T, r *router.Router, cookies ...*http.Cookie) *http.Cookie {
+	writer := serve(r, http.MethodPost, "/login", url.Values{"user_id": {"12"}}.Encode(), cookies...)
+	assert.Equal(t, http.StatusOK, writer.Code)
+	result := writer.Result().Cookies()
+	assert.Equal(t, 1, len(result))
+	return result[0]
+}
+
+func TestManager_Cookie_Sessions(t *testing.T) {
+	for name, encrypt := range map[string]bool{"signed": false, "encrypted": true} {
+		t.Run(name, func(t *testing.T) {
+			m, _ := newTestManager(Options{Keys: [][]byte{testKey1}, Encrypt: encrypt, Secure: true})
+			r := newTestRouter(m)
+
+			writer := serve(r, http.MethodGet, "/profile", "")
+			assert.Equal(t, "anonymous", writer.Body.String())
+			assert.Equal(t, 0, len(writer.Result().Cookies()))
+
+			cookie := login(t, r)
+			assert.Equal(t, "session", cookie.Name)
+			assert.Equal(t, true, cookie.HttpOnly)
+			assert.Equal(t, true, cookie.Secure)
+			assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+			assert.Equal(t, encrypt, !strings.Contains(cookie.Value, "."))
+
+			writer = serve(r, http.MethodGet, "/profile", "", cookie)
+			assert.Equal(t, "admin", writer.Body.String())
+
+			tampered := *cookie
+			tampered.Value = strings.Replace(cookie.Value, cookie.Value[5:6], "A", 1)
+			if tampered.Value == cookie.Value {
+				tampered.Value = strings.Replace(cookie.Value, cookie.Value[5:6], "B", 1)
+			}
+			writer = serve(r, http.MethodGet, "/profile", "", &tampered)
+			assert.Equal(t, "anonymous", writer.Body.String())
+
+			renamed := *cookie
+			renamed.Name = "other"
+			otherManager, _ := newTestManager(Options{Keys: [][]byte{testKey1}, Encrypt: encrypt, CookieName: "other"})
+			writer = serve(newTestRouter(otherManager), http.MethodGet, "/profile", "", &renamed)
+			assert.Equal(t, "anonymous", writer.Body.String())
+		})
+	}
+}
+
+func TestManager_Key_Rotation(t *testing.T) {
+	oldManager, _ := newTestManager(Options{Keys: [][]byte{testKey1}, Encrypt: true})
+	cookie := login(t, newTestRouter(oldManager))
+
+	rotated, _ := newTestManager(Options{Keys: [][]byte{testKey2, testKey1}, Encrypt: true})
+	r := newTestRouter(rotated)
+	writer := serve(r, http.MethodGet, "/profile", "", cookie)
+	assert.Equal(t, "admin", writer.Body.String())
+
+	newCookie := login(t, r, cookie)
+	removed, _ := newTestManager(Options{Keys: [][]byte{testKey2}, Encrypt: true})
+	writer = serve(newTestRouter(removed), http.MethodGet, "/profile", "", newCookie)
+	assert.Equal(t, "admin", writer.Body.String())
+
+	writer = serve(newTestRouter(removed), http.MethodGet, "/profile", "", cookie)
+	assert.Equal(t, "anonymous", writer.Body.String())
+}
+
+func TestNew_Invalid_Keys(t *testing.T) {
+	assert.PanicsWithValue(t, "session: at least one key is required", func() {
+		New(Options{})
+	})
+	assert.PanicsWithValue(t, "session: key 1 must have at least 32 bytes", func() {
+		New(Options{Keys: [][]byte{testKey1, []byte("short")}})
+	})
+}
+
+func TestManager_Expiry(t *testing.T) {
+	for name, store := range map[string]Store{"cookie": nil, "memory": NewMemoryStore()} {
+		t.Run(name, func(t *testing.T) {
+			m, clock := newTestManager(Options{
+				Keys:            [][]byte{testKey1},
+				Store:           store,
+				IdleTimeout:     10 * time.Minute,
+				AbsoluteTimeout: time.Hour,
+			})
+			r := newTestRouter(m)
+
+			t.Run("idle", func(t *testing.T) {
+				cookie := login(t, r)
+
+				clock.now = clock.now.Add(9 * time.Minute)
+				writer := serve(r, http.MethodGet, "/profile", "", cookie)
+				assert.Equal(t, "admin", writer.Body.String())
+				if store == nil {
+					// the access time is kept in the cookie
+					cookie = writer.Result().Cookies()[0]
+				}
+
+				clock.now = clock.now.Add(9 * time.Minute)
+				writer = serve(r, http.MethodGet, "/profile", "", cookie)
+				assert.Equal(t, "admin", writer.Body.String())
+
+				clock.now = clock.now.Add(11 * time.Minute)
+				writer = serve(r, http.MethodGet, "/profile", "", cookie)
+				assert.Equal(t, "anonymous", writer.Body.String())
+				assert.Equal(t, -1, writer.Result().Cookies()[0].MaxAge)
+			})
+
+			t.Run("absolute", func(t *testing.T) {
+				cookie := login(t, r)
+				for i := 0; i < 7; i++ {
+					clock.now = clock.now.Add(9 * time.Minute)
+					writer := serve(r, http.MethodGet, "/profile", "", cookie)
+					if i < 6 {
+						assert.Equal(t, "admin", writer.Body.String())
+					} else {
+						assert.Equal(t, "anonymous", writer.Body.String())
+					}
+					if cookies := writer.Result().Cookies(); store == nil && cookies[0].MaxAge == 0 {
+						cookie = cookies[0]
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestManager_Store(t *testing.T) {
+	store := NewMemoryStore()
+	m, _ := newTestManager(Options{Keys: [][]byte{testKey1}, Store: store})
+	r := newTestRouter(m)
+
+	cookie := login(t, r)
+	assert.Equal(t, 1, len(store.entries))
+
+	t.Run("cookie holds the id", func(t *testing.T) {
+		data, ok := m.codec.decode("session", cookie.Value)
+		assert.Equal(t, true, ok)
+		record, ok, err := store.Load(context.Background(), string(data))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, `{"user_id":12,"role":"admin"}`, string(record.Data))
+	})
+
+	t.Run("regenerate", func(t *testing.T) {
+		newCookie := login(t, r, cookie)
+		assert.NotEqual(t, cookie.Value, newCookie.Value)
+		assert.Equal(t, 1, len(store.entries))
+
+		writer := serve(r, http.MethodGet, "/profile", "", cookie)
+		assert.Equal(t, "anonymous", writer.Body.String())
+		writer = serve(r, http.MethodGet, "/profile", "", newCookie)
+		assert.Equal(t, "admin", writer.Body.String())
+		cookie = newCookie
+	})
+
+	t.Run("destroy", func(t *testing.T) {
+		writer := serve(r, http.MethodPost, "/logout", "confirm=1", cookie)
+		assert.Equal(t, "bye", writer.Body.String())
+		assert.Equal(t, -1, writer.Result().Cookies()[0].MaxAge)
+		assert.Equal(t, 0, len(store.entries))
+
+		writer = serve(r, http.MethodGet, "/profile", "", cookie)
+		assert.Equal(t, "anonymous", writer.Body.String())
+	})
+}
+
+func TestSave_After_Response(t *testing.T) {
+	m, _ := newTestManager(Options{Keys: [][]byte{testKey1}})
+	r := router.NewRouter().WithHTTPMiddlewares(m.Middleware)
+
+	var saveErr error
+	router.HTMLGet(r, profilePath, func(ctx router.Context, req emptyRequest) (template.HTML, error) {
+		ctx.Respond(router.Stream("text/plain", func(w io.Writer) error {
+			_, _ = w.Write([]byte("streamed"))
+			saveErr = Save(ctx, userSession{UserID: 1})
+			return nil
+		}))
+		return "", nil
+	})
+
+	writer := serve(r, http.MethodGet, "/profile", "")
+	assert.Equal(t, "streamed", writer.Body.String())
+	assert.Equal(t, ErrCommitted, saveErr)
+}
+
+func TestManager_CSRF_Store(t *testing.T) {
+	m, _ := newTestManager(Options{Keys: [][]byte{testKey1}})
+	r := router.NewRouter().WithHTTPMiddlewares(m.Middleware).WithOptions(router.WithCSRF(router.CSRFOptions{Store: m}))
+
+	router.HTMLGet(r, profilePath, func(ctx router.Context, req emptyRequest) (template.HTML, error) {
+		return template.HTML(ctx.CSRFToken()), nil
+	})
+	router.HTMLPost(r, logoutPath, func(ctx router.Context, req emptyRequest) (template.HTML, error) {
+		return "bye", nil
+	})
+
+	writer := serve(r, http.MethodGet, "/profile", "")
+	token := writer.Body.String()
+	cookies := writer.Result().Cookies()
+	assert.Equal(t, 1, len(cookies))
+	assert.Equal(t, "session", cookies[0].Name)
+
+	writer = serve(r, http.MethodPost, "/logout", url.Values{"csrf_token": {token}}.Encode(), cookies[0])
+	assert.Equal(t, http.StatusOK, writer.Code)
+
+	writer = serve(r, http.MethodPost, "/logout", url.Values{"csrf_token": {token}}.Encode())
+	assert.Equal(t, http.StatusForbidden, writer.Code)
+}
+
+func TestFileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	store, err := NewFileStore(dir)
+	assert.Equal(t, nil, err)
+
+	ctx := context.Background()
+	record := Record{
+		ID:         strings.Repeat("ab", idSize),
+		Data:       []byte(`{"user_id":1}`),
+		CreatedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		AccessedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	expired := Record{ID: strings.Repeat("cd", idSize)}
+
+	assert.Equal(t, nil, store.Save(ctx, record, time.Now().Add(time.Hour)))
+	assert.Equal(t, nil, store.Save(ctx, expired, time.Now().Add(-time.Second)))
+
+	t.Run("load", func(t *testing.T) {
+		loaded, ok, err := store.Load(ctx, record.ID)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, record, loaded)
+
+		_, ok, err = store.Load(ctx, expired.ID)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		_, ok, err := store.Load(ctx, "../"+strings.Repeat("a", 2*idSize-3))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, ok)
+		assert.Equal(t, errInvalidID, store.Save(ctx, Record{ID: "../x"}, time.Now()))
+	})
+
+	t.Run("delete expired", func(t *testing.T) {
+		assert.Equal(t, nil, store.Save(ctx, expired, time.Now().Add(-time.Second)))
+		assert.Equal(t, nil, store.DeleteExpired())
+
+		entries, err := os.ReadDir(dir)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(entries))
+		assert.Equal(t, record.ID+fileSuffix, entries[0].Name())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.Equal(t, nil, store.Delete(ctx, record.ID))
+		assert.Equal(t, nil, store.Delete(ctx, record.ID))
+		_, ok, err := store.Load(ctx, record.ID)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, ok)
+	})
+}
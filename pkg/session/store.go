@@ -0,0 +1,218 @@
+package session
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is the state of a session, it is kept in the cookie or in the Store of the Manager
+type Record struct {
+	ID         string          `json:"id"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	CSRFToken  string          `json:"csrf_token,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	AccessedAt time.Time       `json:"accessed_at"`
+}
+
+// Store keeps the records of the sessions on the server side, the cookie then only holds the session id
+type Store interface {
+	// Load returns the record of the session, false if it does not exist or has expired
+	Load(ctx context.Context, id string) (Record, bool, error)
+	// Save creates or replaces the record, which can be deleted after expiresAt
+	Save(ctx context.Context, record Record, expiresAt time.Time) error
+	Delete(ctx context.Context, id string) error
+}
+
+type memoryEntry struct {
+	record    Record
+	expiresAt time.Time
+}
+
+// MemoryStore keeps the records in memory, the sessions are lost when the process exits
+type MemoryStore struct {
+	mut     sync.Mutex
+	entries map[string]memoryEntry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]memoryEntry{}}
+}
+
+func (s *MemoryStore) Load(ctx context.Context, id string) (Record, bool, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return Record{}, false, nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(s.entries, id)
+		return Record{}, false, nil
+	}
+	return entry.record, true, nil
+}
+
+func (s *MemoryStore) Save(ctx context.Context, record Record, expiresAt time.Time) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.entries[record.ID] = memoryEntry{record: record, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	delete(s.entries, id)
+	return nil
+}
+
+// DeleteExpired removes the expired records, it should be called periodically
+func (s *MemoryStore) DeleteExpired() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	now := time.Now()
+	for id, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, id)
+		}
+	}
+}
+
+// FileStore keeps each record in a json file of a directory, the files are replaced atomically
+type FileStore struct {
+	dir string
+}
+
+type fileEntry struct {
+	Record    Record    `json:"record"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+const fileSuffix = ".session.json"
+
+var errInvalidID = errors.New("session: invalid session id")
+
+// NewFileStore creates the directory if it does not exist
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path returns the file of the session, the id is checked so that it can not escape the directory
+func (s *FileStore) path(id string) (string, error) {
+	if len(id) != 2*idSize {
+		return "", errInvalidID
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return "", errInvalidID
+	}
+	return filepath.Join(s.dir, id+fileSuffix), nil
+}
+
+func (s *FileStore) Load(ctx context.Context, id string) (Record, bool, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return Record{}, false, nil
+	}
+
+	entry, err := readFileEntry(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Record{}, false, nil
+	}
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	if time.Now().After(entry.ExpiresAt) {
+		_ = os.Remove(path)
+		return Record{}, false, nil
+	}
+	return entry.Record, true, nil
+}
+
+func readFileEntry(path string) (fileEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileEntry{}, err
+	}
+	var entry fileEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return fileEntry{}, err
+	}
+	return entry, nil
+}
+
+func (s *FileStore) Save(ctx context.Context, record Record, expiresAt time.Time) error {
+	path, err := s.path(record.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(fileEntry{Record: record, ExpiresAt: expiresAt})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+func (s *FileStore) Delete(ctx context.Context, id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// DeleteExpired removes the files of the expired records, it should be called periodically
+func (s *FileStore) DeleteExpired() error {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), fileSuffix) {
+			continue
+		}
+		path := filepath.Join(s.dir, dirEntry.Name())
+		entry, err := readFileEntry(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil || now.After(entry.ExpiresAt) {
+			_ = os.Remove(path)
+		}
+	}
+	return nil
+}
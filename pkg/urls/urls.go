@@ -1,6 +1,7 @@
 package urls
 
 import (
+	"encoding"
 	"fmt"
 	"learn-gin/pkg/null"
 	"net/url"
@@ -45,11 +46,11 @@ func (p Path[T]) GetAllParams() []string {
 	return slices.Clone(p.allParams)
 }
 
-func getFieldValues(obj any) map[string]string {
+func getFieldValues(obj any, pathParams []string) (map[string][]string, error) {
 	objVal := reflect.ValueOf(obj)
 	objType := objVal.Type()
 
-	result := map[string]string{}
+	result := map[string][]string{}
 
 	for i := 0; i < objVal.NumField(); i++ {
 		f := objVal.Field(i)
@@ -60,31 +61,126 @@ func getFieldValues(obj any) map[string]string {
 		fieldType := objType.Field(i)
 		jsonName := computeJSONName(fieldType.Tag.Get("json"))
 
-		var val string
+		values, ok, err := FormatValues(f)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
 
-		validVal, dataVal, ok := null.IsNullType(f)
-		if ok {
-			if validVal.Bool() {
-				val = fmt.Sprint(dataVal.Interface())
+		if isSliceField(fieldType.Type) && (slices.Contains(pathParams, jsonName) || IsCommaSeparated(fieldType)) {
+			value, err := JoinValues(jsonName, values)
+			if err != nil {
+				return nil, err
 			}
-		} else {
-			val = fmt.Sprint(f.Interface())
+			values = []string{value}
+		}
+		result[jsonName] = values
+	}
+
+	return result, nil
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+func isSliceField(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Slice && !reflect.PointerTo(typ).Implements(textMarshalerType)
+}
+
+// IsCommaSeparated reports whether a slice query param is opted into the single comma separated value
+// with the struct tag `explode:"false"`, instead of the repeated keys
+func IsCommaSeparated(field reflect.StructField) bool {
+	return field.Tag.Get("explode") == "false"
+}
+
+// JoinValues joins the values of a slice param with commas,
+// a value containing a comma is rejected because it could not be split back by the router param binding
+func JoinValues(name string, values []string) (string, error) {
+	for _, value := range values {
+		if strings.Contains(value, ",") {
+			return "", fmt.Errorf("urls: value '%s' of param '%s' contains a comma", value, name)
 		}
+	}
+	return strings.Join(values, ","), nil
+}
 
-		result[jsonName] = val
+// FormatValues formats a field value the same way it is parsed by the router param binding.
+// Null values and nil pointers are absent, slices are formatted to multiple values.
+func FormatValues(val reflect.Value) ([]string, bool, error) {
+	validVal, dataVal, ok := null.IsNullType(val)
+	if ok {
+		if !validVal.Bool() {
+			return nil, false, nil
+		}
+		return FormatValues(dataVal)
 	}
 
-	return result
+	if val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return nil, false, nil
+		}
+		return FormatValues(val.Elem())
+	}
+
+	if isSliceField(val.Type()) {
+		result := make([]string, 0, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			value, err := formatScalar(val.Index(i))
+			if err != nil {
+				return nil, false, err
+			}
+			result = append(result, value)
+		}
+		return result, true, nil
+	}
+
+	value, err := formatScalar(val)
+	if err != nil {
+		return nil, false, err
+	}
+	return []string{value}, true, nil
+}
+
+func formatScalar(val reflect.Value) (string, error) {
+	if val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return "", nil
+		}
+		val = val.Elem()
+	}
+
+	if val.Type().Implements(textMarshalerType) {
+		data, err := val.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(data), err
+	}
+	if reflect.PointerTo(val.Type()).Implements(textMarshalerType) {
+		ptr := reflect.New(val.Type())
+		ptr.Elem().Set(val)
+		data, err := ptr.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(data), err
+	}
+
+	return fmt.Sprint(val.Interface()), nil
 }
 
+// Eval returns the url of the path with the path params and the query params of param,
+// it panics if a value can not be formatted, see TryEval
 func (p Path[T]) Eval(param T) string {
-	var buf strings.Builder
+	result, err := p.TryEval(param)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
 
-	paramVales := getFieldValues(param)
+// TryEval is like Eval but returns an error if a value can not be formatted
+func (p Path[T]) TryEval(param T) (string, error) {
+	var buf strings.Builder
 
-	pathParamSet := map[string]struct{}{}
-	for _, pathParam := range p.pathParams {
-		pathParamSet[pathParam] = struct{}{}
+	paramVales, err := getFieldValues(param, p.pathParams)
+	if err != nil {
+		return "", err
 	}
 
 	pattern := p.pattern
@@ -105,14 +201,16 @@ func (p Path[T]) Eval(param T) string {
 			key = key[:colonIndex]
 		}
 
-		buf.WriteString(paramVales[key])
+		if values := paramVales[key]; len(values) > 0 {
+			buf.WriteString(url.PathEscape(values[0]))
+		}
 		delete(paramVales, key)
 
 		pattern = pattern[end+1:]
 	}
 
 	if len(paramVales) == 0 {
-		return buf.String()
+		return buf.String(), nil
 	}
 
 	keys := make([]string, 0, len(paramVales))
@@ -123,13 +221,13 @@ func (p Path[T]) Eval(param T) string {
 
 	queryParams := url.Values{}
 	for _, k := range keys {
-		queryParams.Set(k, paramVales[k])
+		queryParams[k] = paramVales[k]
 	}
 
 	buf.WriteString("?")
 	buf.WriteString(queryParams.Encode())
 
-	return buf.String()
+	return buf.String(), nil
 }
 
 func checkMustBeStruct(objType reflect.Type) {
@@ -1,9 +1,11 @@
 package urls
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"learn-gin/pkg/null"
 	"testing"
+	"time"
 )
 
 func TestFindPathParams(t *testing.T) {
@@ -234,3 +236,119 @@ func TestPath_Eval(t *testing.T) {
 		}))
 	})
 }
+
+type testLevel int
+
+func (l testLevel) MarshalText() ([]byte, error) {
+	if l < 0 || l > 1 {
+		return nil, errors.New("invalid level")
+	}
+	return []byte([]string{"low", "high"}[l]), nil
+}
+
+func TestPath_Eval_Rich_Scalars(t *testing.T) {
+	t.Run("scalars", func(t *testing.T) {
+		type request struct {
+			Name    string        `json:"name"`
+			Active  bool          `json:"active"`
+			Score   float64       `json:"score"`
+			Since   time.Time     `json:"since"`
+			Timeout time.Duration `json:"timeout"`
+			Level   testLevel     `json:"level"`
+			Limit   *int          `json:"limit"`
+		}
+
+		p := New[request]("/api/users/{name}/list")
+
+		limit := 0
+		assert.Equal(t,
+			"/api/users/user01/list?active=true&level=high&limit=0&score=1.5"+
+				"&since=2024-01-02T03%3A04%3A05Z&timeout=1m30s",
+			p.Eval(request{
+				Name:    "user01",
+				Active:  true,
+				Score:   1.5,
+				Since:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Timeout: 90 * time.Second,
+				Level:   1,
+				Limit:   &limit,
+			}),
+		)
+	})
+
+	t.Run("slices", func(t *testing.T) {
+		type request struct {
+			IDs  []int    `json:"ids"`
+			Tags []string `json:"tags"`
+		}
+
+		p := New[request]("/api/users/{ids}/list")
+
+		assert.Equal(t, "/api/users/1%2C2/list?tags=a&tags=b", p.Eval(request{
+			IDs:  []int{1, 2},
+			Tags: []string{"a", "b"},
+		}))
+	})
+
+	t.Run("comma separated", func(t *testing.T) {
+		type request struct {
+			IDs  []int    `json:"ids"`
+			Tags []string `json:"tags" explode:"false"`
+		}
+
+		p := New[request]("/api/users/{ids}/list")
+
+		assert.Equal(t, "/api/users/1%2C2/list?tags=a%2Cb", p.Eval(request{
+			IDs:  []int{1, 2},
+			Tags: []string{"a", "b"},
+		}))
+	})
+
+	t.Run("comma in values", func(t *testing.T) {
+		type request struct {
+			Names []string `json:"names"`
+			Tags  []string `json:"tags"`
+			Keys  []string `json:"keys" explode:"false"`
+		}
+
+		p := New[request]("/api/users/{names}/list")
+
+		assert.Equal(t, "/api/users/a/list?tags=b%2Cc&tags=d", p.Eval(request{
+			Names: []string{"a"},
+			Tags:  []string{"b,c", "d"},
+		}))
+
+		_, err := p.TryEval(request{Names: []string{"a,b"}})
+		assert.Equal(t, errors.New("urls: value 'a,b' of param 'names' contains a comma"), err)
+
+		_, err = p.TryEval(request{Names: []string{"a"}, Keys: []string{"b,c"}})
+		assert.Equal(t, errors.New("urls: value 'b,c' of param 'keys' contains a comma"), err)
+	})
+
+	t.Run("marshal error", func(t *testing.T) {
+		type request struct {
+			Level  testLevel   `json:"level"`
+			Levels []testLevel `json:"levels"`
+		}
+
+		p := New[request]("/api/users")
+
+		_, err := p.TryEval(request{Level: 2})
+		assert.Equal(t, errors.New("invalid level"), err)
+
+		_, err = p.TryEval(request{Levels: []testLevel{1, 5}})
+		assert.Equal(t, errors.New("invalid level"), err)
+	})
+
+	t.Run("escape path param", func(t *testing.T) {
+		type request struct {
+			Name string `json:"name"`
+		}
+
+		p := New[request]("/api/users/{name}/list")
+
+		assert.Equal(t, "/api/users/a%2Fb%20c/list", p.Eval(request{
+			Name: "a/b c",
+		}))
+	})
+}
@@ -2,15 +2,54 @@ package views
 
 import (
 	"bytes"
+	"context"
 	"html/template"
+	"sync"
 )
 
+// RequestContext is the context of the request of a render, it is implemented by router.Context
+// so that the templates render its csrf token and trace the render without depending on the router
+type RequestContext interface {
+	context.Context
+	CSRFToken() string
+	// CSRFField returns the hidden input of the token, in the multipart forms it must precede the file inputs
+	CSRFField() template.HTML
+	// StartSpan starts a child span of the request, end records the error and ends the span
+	StartSpan(name string) (end func(err error))
+}
+
 type Template struct {
+	tmpl      *template.Template
+	renderers sync.Pool
+}
+
+// renderer is a clone of the template with the functions bound to the request of its current render,
+// the renderers are reused so that the template is only cloned and escaped once per concurrent render
+type renderer struct {
 	tmpl *template.Template
+	req  RequestContext
+}
+
+// requestFuncs returns the template functions of the csrf token of the request, they render nothing without request
+func requestFuncs(req func() RequestContext) template.FuncMap {
+	return template.FuncMap{
+		"csrfField": func() template.HTML {
+			if r := req(); r != nil {
+				return r.CSRFField()
+			}
+			return ""
+		},
+		"csrfToken": func() string {
+			if r := req(); r != nil {
+				return r.CSRFToken()
+			}
+			return ""
+		},
+	}
 }
 
 func Load(templateStr string) *Template {
-	t, err := template.New("empty").Parse(templateStr)
+	t, err := template.New("empty").Funcs(requestFuncs(func() RequestContext { return nil })).Parse(templateStr)
 	if err != nil {
 		panic(err)
 	}
@@ -19,9 +58,45 @@ func Load(templateStr string) *Template {
 	}
 }
 
+// RenderContext renders the template for the request if ctx is a RequestContext, e.g. a router.Context,
+// inside a span of the request, the csrfField and csrfToken functions render the csrf token of the request
+func (t *Template) RenderContext(ctx context.Context, data any) (template.HTML, error) {
+	req, ok := ctx.(RequestContext)
+	if !ok {
+		return t.execute(nil, data)
+	}
+
+	end := req.StartSpan("views.Render")
+	html, err := t.execute(req, data)
+	end(err)
+	return html, err
+}
+
+// Render renders the template without the values of a request, e.g. csrfField renders nothing
 func (t *Template) Render(data any) (template.HTML, error) {
+	return t.execute(nil, data)
+}
+
+// execute renders the template with a renderer bound to the request,
+// the parsed template is never executed so that it can always be cloned
+func (t *Template) execute(req RequestContext, data any) (template.HTML, error) {
+	r, ok := t.renderers.Get().(*renderer)
+	if !ok {
+		tmpl, err := t.tmpl.Clone()
+		if err != nil {
+			return "", err
+		}
+		r = &renderer{}
+		r.tmpl = tmpl.Funcs(requestFuncs(func() RequestContext { return r.req }))
+	}
+	r.req = req
+	defer func() {
+		r.req = nil
+		t.renderers.Put(r)
+	}()
+
 	var buf bytes.Buffer
-	err := t.tmpl.Execute(&buf, data)
+	err := r.tmpl.Execute(&buf, data)
 	if err != nil {
 		return "", err
 	}
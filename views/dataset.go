@@ -0,0 +1,13 @@
+package views
+
+import (
+	_ "embed"
+)
+
+//go:embed dataset.html
+var datasetTmplStr string
+var DatasetTemplate = Load(datasetTmplStr)
+
+type DatasetData struct {
+	DatasetID int64 `json:"dataset_id"`
+}
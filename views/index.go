@@ -1,6 +1,7 @@
 package views
 
 import (
+	"context"
 	_ "embed"
 	"html/template"
 )
@@ -12,6 +13,6 @@ var indexTmpl = Load(indexTmplStr)
 type IndexData struct {
 }
 
-func Index(data IndexData) (template.HTML, error) {
-	return indexTmpl.Render(data)
+func Index(ctx context.Context, data IndexData) (template.HTML, error) {
+	return indexTmpl.RenderContext(ctx, data)
 }